@@ -23,14 +23,30 @@ usage() {
     echo "  frida           Traditional FRI benchmarking (commitment + proof + verification)"
     echo "  single-frida    Single proof size and time analysis"
     echo "  defrida         Distributed deFRIDA workflow benchmarking"
+    echo "  compare OLD NEW Compare two per-run profiles ('frida custom --profile-runs') phase by phase"
     echo ""
     echo "Commands:"
     echo "  full            Run comprehensive benchmark suite"
     echo "  custom          Run custom benchmark with specified parameters"
+    echo "  presets         (frida only) List the named sweeps for 'full --preset'"
     echo "  help            Show this help message"
     echo ""
     echo "Common Options:"
     echo "  --output FILE   Output CSV file (default varies by benchmark type)"
+    echo "  --skip-preflight  (full only) Skip the quick end-to-end check run before the sweep"
+    echo "  --label TEXT    Free-form run label recorded with the machine metadata in every result file"
+    echo ""
+    echo "Frida Full Options:"
+    echo "  --preset NAME               Start from a named sweep (list them with 'frida presets')"
+    echo "  --runs N                    Runs per configuration (default: 10)"
+    echo "  --warmup N                  Untimed runs before each configuration (default: 0)"
+    echo "  --seed N                    Derive every input blob from N"
+    echo "  --fri-options B:F:R,...     FRI options to sweep, e.g. 2:2:0,2:8:4"
+    echo "  --data-sizes SIZE,...       Encoded sizes to sweep, e.g. 124K,4M"
+    echo "  --num-queries N,...         Query counts to sweep (default: 8,16,32)"
+    echo "  --batch-sizes N,...         Batch sizes to sweep (default: 2,4,8,16)"
+    echo "  --json-output FILE          Also append each result to FILE as a JSON line"
+    echo "  --scaling-output FILE       Write per-configuration scaling exponents to FILE as JSON lines"
     echo ""
     echo "Frida Custom Options:"
     echo "  --blowup-factor N           Blowup factor (required)"
@@ -39,6 +55,27 @@ usage() {
     echo "  --data-size N               Data size in bytes (required)"
     echo "  --batch-size N              Batch size (default: 1)"
     echo "  --num-queries N             Number of queries (default: 32)"
+    echo "  --hash NAME                 Merkle and Fiat-Shamir hash: blake3 (default) or sha3"
+    echo "  --determinism-check         Abort unless two passes on identical data produce identical artifacts"
+    echo "  --sanity                    Abort unless corrupted proofs and evaluations fail verification"
+    echo "  --verify-positions LIST     Open and verify each listed position, e.g. 0,17,4095, and report per position"
+    echo "  --track-memory              Record allocations and peak heap per phase in an untimed run"
+    echo "  --adaptive-runs             Run until the proof time 95% CI is within --ci percent of the mean"
+    echo "  --ci PCT                    Target CI half-width for --adaptive-runs (default: 5)"
+    echo "  --max-runs N                Run cap for --adaptive-runs (default: 100)"
+    echo "  --warmup N                  Untimed runs before the measured runs (default: 0)"
+    echo "  --seed N                    Derive inputs from N and print input/commitment hashes"
+    echo "  --profile-runs N            Run N times, write a per-run profile and recommend a warmup count"
+    echo "  --profile-output FILE       Per-run profile CSV (default: bench/results/frida_profile.csv)"
+    echo "  --budget-check PHASE<=DUR   Fail if any run of PHASE exceeds DUR, e.g. proof_32<=3s (repeatable)"
+    echo ""
+    echo "Frida Fragmentation Options:"
+    echo "  --blowup-factor, --folding-factor, --max-remainder-degree, --data-size, --batch-size as above"
+    echo "  --position-counts LIST      Values of K to sweep (default: 1,2,4,8,16,32)"
+    echo ""
+    echo "Frida Recovery Options:"
+    echo "  --blowup-factor, --folding-factor, --max-remainder-degree as above"
+    echo "  --data-sizes N,...          Data sizes in bytes (default: 1024,4096,16384)"
     echo ""
     echo "Single-Frida Custom Options:"
     echo "  --blowup-factor N           Blowup factor (required)"
@@ -55,6 +92,7 @@ usage() {
     echo "  --num-validators N          Number of validators (required)"
     echo "  --num-queries N             Number of queries (required)"
     echo "  --batch-size N              Batch size (default: 1)"
+    echo "  --validator-breakdown DIR   Also write a per-validator CSV into DIR"
     echo ""
     echo "Examples:"
     echo "  $0 frida full"
@@ -85,7 +123,7 @@ ARGS=()
 # First argument should be benchmark type
 if [[ $# -gt 0 ]]; then
     case $1 in
-        frida|single-frida|defrida|help)
+        frida|single-frida|defrida|compare|help)
             BENCHMARK_TYPE="$1"
             shift
             ;;
@@ -99,9 +137,9 @@ if [[ $# -gt 0 ]]; then
 fi
 
 # Second argument should be command
-if [[ $# -gt 0 && "$BENCHMARK_TYPE" != "help" ]]; then
+if [[ $# -gt 0 && "$BENCHMARK_TYPE" != "help" && "$BENCHMARK_TYPE" != "compare" ]]; then
     case $1 in
-        full|custom)
+        full|custom|fragmentation|recovery|presets)
             COMMAND="$1"
             shift
             ;;
@@ -136,6 +174,17 @@ case $BENCHMARK_TYPE in
                 echo -e "${BLUE}Running custom Frida benchmark...${NC}"
                 ./target/release/frida-bench frida $COMMAND "${ARGS[@]}"
                 ;;
+            "fragmentation")
+                echo -e "${BLUE}Running Frida verification fragmentation benchmark...${NC}"
+                ./target/release/frida-bench frida $COMMAND "${ARGS[@]}"
+                ;;
+            "recovery")
+                echo -e "${BLUE}Running Frida recovery benchmark...${NC}"
+                ./target/release/frida-bench frida $COMMAND "${ARGS[@]}"
+                ;;
+            "presets")
+                ./target/release/frida-bench frida $COMMAND
+                ;;
             *)
                 echo -e "${RED}Error: Missing or invalid command for frida benchmark${NC}"
                 usage
@@ -183,6 +232,10 @@ case $BENCHMARK_TYPE in
                 ;;
         esac
         ;;
+    "compare")
+        build_benchmark
+        ./target/release/frida-bench compare "${ARGS[@]}"
+        ;;
     *)
         echo -e "${RED}Error: Missing benchmark type${NC}"
         echo ""
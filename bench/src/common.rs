@@ -1,11 +1,93 @@
-use std::{fs, io::Write, path::Path};
+use serde::Serialize;
+use std::{fs, io::Write, ops::Add, path::Path, sync::OnceLock, thread, time::Duration};
+use winter_crypto::hashers::{Blake3_256, Sha3_256};
 use winter_math::{
     fields::{f128, f64},
-    FieldElement,
+    FieldElement, StarkField,
 };
+use winter_rand_utils::{prng_vector, rand_vector};
+
+use frida_poc::{constants, core::data::encoded_data_element_count};
+
+use crate::stats;
 
 pub const RUNS: usize = 10;
 
+/// Serializes the tests that run the prover, which with the `bench` feature records its phase
+/// times in process-wide statics that concurrent tests would overwrite.
+#[cfg(test)]
+static BENCH_LOCK: std::sync::Mutex<()> = std::sync::Mutex::new(());
+
+/// Holds [`BENCH_LOCK`] for the rest of the test; a test that failed while holding it does not
+/// fail the others.
+#[cfg(test)]
+pub fn lock_prover() -> std::sync::MutexGuard<'static, ()> {
+    BENCH_LOCK
+        .lock()
+        .unwrap_or_else(std::sync::PoisonError::into_inner)
+}
+
+/// Share of a configuration's wall time, in percent, spent outside timed phases above which a
+/// warning is printed.
+pub const OVERHEAD_WARN_PCT: f64 = 25.0;
+
+/// Minimum number of runs before an adaptive policy checks its confidence interval.
+pub const MIN_ADAPTIVE_RUNS: usize = 3;
+
+/// Where the bytes the benchmarks commit to come from.
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+pub enum InputSource {
+    /// Fresh random bytes for every blob.
+    #[default]
+    Random,
+    /// Bytes derived from the seed and the blob's index, the same on every run and machine.
+    Seeded(u64),
+}
+
+impl InputSource {
+    /// Returns the `index`-th blob of `size` bytes.
+    pub fn blob(&self, index: usize, size: usize) -> Vec<u8> {
+        match *self {
+            InputSource::Random => rand_vector(size),
+            InputSource::Seeded(seed) => {
+                let mut prng_seed = [0u8; 32];
+                prng_seed[..8].copy_from_slice(&seed.to_le_bytes());
+                prng_seed[8..16].copy_from_slice(&(index as u64).to_le_bytes());
+                prng_vector(prng_seed, size)
+            }
+        }
+    }
+}
+
+/// Version of the JSON result records; bump it whenever a field is renamed, removed or changes
+/// meaning.
+pub const RESULTS_SCHEMA_VERSION: u32 = 2;
+
+/// Decides how many iterations a benchmark configuration is run for.
+#[derive(Debug, Clone, Copy)]
+pub enum RunPolicy {
+    /// Always run exactly this many iterations.
+    Fixed(usize),
+    /// Keep running until the 95% confidence interval of the tracked sample mean is within
+    /// `ci_pct` percent of the mean, or `max_runs` is reached.
+    Adaptive { ci_pct: f64, max_runs: usize },
+}
+
+impl RunPolicy {
+    /// Returns true if another iteration should be run given the samples collected so far.
+    pub fn needs_more_runs(&self, samples: &[f64]) -> bool {
+        match *self {
+            RunPolicy::Fixed(runs) => samples.len() < runs,
+            RunPolicy::Adaptive { ci_pct, max_runs } => {
+                if samples.len() >= max_runs {
+                    return false;
+                }
+                samples.len() < MIN_ADAPTIVE_RUNS || stats::ci_relative_pct(samples) > ci_pct
+            }
+        }
+    }
+}
+
 pub fn get_standard_fri_options() -> Vec<(usize, usize, usize)> {
     vec![
         (2, 2, 0),
@@ -19,14 +101,25 @@ pub fn get_standard_fri_options() -> Vec<(usize, usize, usize)> {
     ]
 }
 
+/// Sizes, in bytes of encoded field elements, covered by the standard sweeps.
+pub fn get_standard_encoded_sizes() -> Vec<usize> {
+    vec![128 * 1024, 256 * 1024, 512 * 1024, 1024 * 1024, 2048 * 1024]
+}
+
+/// Smallest encoded size that leaves room for data after the length prefix in both fields.
+pub const MIN_ENCODED_SIZE: usize = 16;
+
+/// Returns the data size that exactly fills `encoded_size` bytes of `E` elements: every element
+/// carries one byte less than its size, and the encoding prepends an 8-byte length.
+pub fn data_size_for_encoded_size<E: FieldElement>(encoded_size: usize) -> usize {
+    encoded_size / E::ELEMENT_BYTES * (E::ELEMENT_BYTES - 1) - 8
+}
+
 pub fn get_standard_data_sizes<E: FieldElement>() -> Vec<usize> {
-    vec![
-        (128 * 1024) / E::ELEMENT_BYTES * (E::ELEMENT_BYTES - 1) - 8,
-        (256 * 1024) / E::ELEMENT_BYTES * (E::ELEMENT_BYTES - 1) - 8,
-        (512 * 1024) / E::ELEMENT_BYTES * (E::ELEMENT_BYTES - 1) - 8,
-        (1024 * 1024) / E::ELEMENT_BYTES * (E::ELEMENT_BYTES - 1) - 8,
-        (2048 * 1024) / E::ELEMENT_BYTES * (E::ELEMENT_BYTES - 1) - 8,
-    ]
+    get_standard_encoded_sizes()
+        .into_iter()
+        .map(data_size_for_encoded_size::<E>)
+        .collect()
 }
 
 pub fn get_standard_num_queries() -> Vec<usize> {
@@ -41,6 +134,394 @@ pub fn get_standard_validator_counts() -> Vec<usize> {
     vec![4, 8, 16, 32, 64, 128, 512, 1024]
 }
 
+/// Work done by one configuration, summed over a sweep for headline totals.
+#[derive(Debug, Default, Clone, Copy, PartialEq, Eq)]
+pub struct SweepTotals {
+    pub data_bytes: usize,
+    pub proofs: usize,
+    pub verifications: usize,
+}
+
+impl Add for SweepTotals {
+    type Output = Self;
+
+    fn add(self, other: Self) -> Self {
+        SweepTotals {
+            data_bytes: self.data_bytes + other.data_bytes,
+            proofs: self.proofs + other.proofs,
+            verifications: self.verifications + other.verifications,
+        }
+    }
+}
+
+impl SweepTotals {
+    pub fn summary(&self, elapsed: Duration) -> String {
+        format!(
+            "Sweep totals: {:.3} GB of data processed, {} proofs generated, {} verifications performed in {:.1} s",
+            self.data_bytes as f64 / (1024.0 * 1024.0 * 1024.0),
+            self.proofs,
+            self.verifications,
+            elapsed.as_secs_f64()
+        )
+    }
+}
+
+/// Returns the log2 of the evaluation domain size and the extension factor, i.e. the domain size
+/// relative to the number of field elements holding the encoded data.
+pub fn domain_geometry(domain_size: usize, encoded_element_count: usize) -> (u32, f64) {
+    assert!(
+        domain_size.is_power_of_two(),
+        "Domain size {domain_size} is not a power of two"
+    );
+    assert!(
+        encoded_element_count > 0 && encoded_element_count <= domain_size,
+        "Encoded element count {encoded_element_count} does not fit domain size {domain_size}"
+    );
+    (
+        domain_size.ilog2(),
+        domain_size as f64 / encoded_element_count as f64,
+    )
+}
+
+/// Returns the evaluation domain size the prover picks for blobs of `data_size` bytes, following
+/// its rules for unbatched and batched data.
+pub fn required_domain_size<E: FieldElement>(
+    data_size: usize,
+    batch_size: usize,
+    blowup_factor: usize,
+) -> usize {
+    let element_count = encoded_data_element_count::<E>(data_size);
+    let domain_size = if batch_size > 1 {
+        (element_count * blowup_factor).next_power_of_two()
+    } else {
+        element_count.next_power_of_two() * blowup_factor
+    };
+    domain_size.max(constants::MIN_DOMAIN_SIZE)
+}
+
+/// Checks before running a configuration that its domain does not exceed the prover's maximum,
+/// which would otherwise only surface as a bare error from the commitment. The error names the
+/// largest data size per blob that fits with the same blowup factor.
+pub fn check_domain_fits<E: FieldElement>(
+    data_size: usize,
+    batch_size: usize,
+    blowup_factor: usize,
+) -> Result<(), String> {
+    let domain_size = required_domain_size::<E>(data_size, batch_size, blowup_factor);
+    if domain_size <= constants::MAX_DOMAIN_SIZE {
+        return Ok(());
+    }
+    let max_encoded_size = constants::MAX_DOMAIN_SIZE / blowup_factor * E::ELEMENT_BYTES;
+    Err(format!(
+        "domain of 2^{} exceeds the maximum of 2^{}; at most {} bytes per blob fit with blowup factor {blowup_factor}",
+        domain_size.ilog2(),
+        constants::MAX_DOMAIN_SIZE.ilog2(),
+        data_size_for_encoded_size::<E>(max_encoded_size)
+    ))
+}
+
+/// Size of one blob at each step from input bytes to the polynomial that is committed to.
+#[derive(Debug, Clone, Copy, PartialEq, Serialize)]
+pub struct DataFootprint {
+    pub data_size_bytes: usize,
+    /// Field elements holding the length prefix and the data, one byte short of full each.
+    pub encoded_size_bytes: usize,
+    /// Field elements after padding to the polynomial length, i.e. the domain before blowup.
+    pub padded_size_bytes: usize,
+    /// How much larger the padded polynomial is than the data, in percent of the data size.
+    pub padding_overhead_pct: f64,
+    /// Evaluations that equal the encoded data, at every blowup-factor-th position of the domain.
+    pub systematic_evaluations: usize,
+    /// Evaluations at the remaining systematic positions, which hold only the zero padding.
+    pub padding_evaluations: usize,
+    /// Evaluations at all other positions, which carry redundancy only.
+    pub parity_evaluations: usize,
+}
+
+impl DataFootprint {
+    pub fn new<E: FieldElement>(
+        data_size: usize,
+        domain_size: usize,
+        blowup_factor: usize,
+    ) -> Self {
+        let encoded_count = encoded_data_element_count::<E>(data_size);
+        let padded_count = domain_size / blowup_factor;
+        assert!(
+            encoded_count <= padded_count,
+            "{encoded_count} encoded elements do not fit a domain of {domain_size} with blowup factor {blowup_factor}"
+        );
+        let padded_size_bytes = padded_count * E::ELEMENT_BYTES;
+        let footprint = DataFootprint {
+            data_size_bytes: data_size,
+            encoded_size_bytes: encoded_count * E::ELEMENT_BYTES,
+            padded_size_bytes,
+            padding_overhead_pct: if data_size == 0 {
+                0.0
+            } else {
+                (padded_size_bytes as f64 / data_size as f64 - 1.0) * 100.0
+            },
+            systematic_evaluations: encoded_count,
+            padding_evaluations: padded_count - encoded_count,
+            parity_evaluations: domain_size - padded_count,
+        };
+        assert_eq!(
+            footprint.systematic_evaluations
+                + footprint.padding_evaluations
+                + footprint.parity_evaluations,
+            domain_size
+        );
+        footprint
+    }
+}
+
+/// Parses a `--data-size` value. Empty data cannot be committed to, so zero is rejected here
+/// rather than deep inside the prover.
+pub fn parse_data_size(value: &str) -> Result<usize, String> {
+    let data_size = value.parse::<usize>().map_err(|e| e.to_string())?;
+    if data_size == 0 {
+        return Err("data size must be at least 1 byte".to_string());
+    }
+    Ok(data_size)
+}
+
+/// Parses a size in bytes with an optional binary `K` or `M` suffix, e.g. `128K` or `2M`.
+pub fn parse_encoded_size(value: &str) -> Result<usize, String> {
+    let (number, scale) = match value.as_bytes().last() {
+        Some(b'K' | b'k') => (&value[..value.len() - 1], 1024),
+        Some(b'M' | b'm') => (&value[..value.len() - 1], 1024 * 1024),
+        _ => (value, 1),
+    };
+    let size = number
+        .parse::<usize>()
+        .ok()
+        .and_then(|n| n.checked_mul(scale))
+        .ok_or_else(|| format!("invalid size '{value}', expected e.g. 4096, 128K or 2M"))?;
+    if size < MIN_ENCODED_SIZE {
+        return Err(format!(
+            "size '{value}' is below the minimum of {MIN_ENCODED_SIZE} bytes"
+        ));
+    }
+    Ok(size)
+}
+
+/// Parses FRI options written as `blowup:folding:max_remainder_degree`, e.g. `2:4:2`.
+pub fn parse_fri_option(value: &str) -> Result<(usize, usize, usize), String> {
+    let parts = value
+        .split(':')
+        .map(str::parse::<usize>)
+        .collect::<Result<Vec<_>, _>>()
+        .map_err(|_| format!("invalid FRI options '{value}'"))?;
+    let [blowup_factor, folding_factor, max_remainder_degree] = parts[..] else {
+        return Err(format!(
+            "FRI options '{value}' must look like blowup:folding:max_remainder_degree"
+        ));
+    };
+    if blowup_factor < 2 || !blowup_factor.is_power_of_two() {
+        return Err(format!(
+            "blowup factor {blowup_factor} must be a power of two of at least 2"
+        ));
+    }
+    if ![2, 4, 8, 16].contains(&folding_factor) {
+        return Err(format!(
+            "folding factor {folding_factor} must be one of 2, 4, 8 or 16"
+        ));
+    }
+    Ok((blowup_factor, folding_factor, max_remainder_degree))
+}
+
+/// Parses a batch size for a sweep's batched configurations, which always hold several polynomials.
+pub fn parse_sweep_batch_size(value: &str) -> Result<usize, String> {
+    let batch_size = value.parse::<usize>().map_err(|e| e.to_string())?;
+    if batch_size < 2 {
+        return Err(
+            "batched sweeps need a batch size of at least 2 (unbatched data is always run)"
+                .to_string(),
+        );
+    }
+    Ok(batch_size)
+}
+
+/// Parses a run count; every configuration needs at least one run to report on.
+pub fn parse_run_count(value: &str) -> Result<usize, String> {
+    let runs = value.parse::<usize>().map_err(|e| e.to_string())?;
+    if runs == 0 {
+        return Err("run count must be at least 1".to_string());
+    }
+    Ok(runs)
+}
+
+/// Parses the run cap of an adaptive policy, which must leave room for the runs taken before the
+/// confidence interval is first checked.
+pub fn parse_max_runs(value: &str) -> Result<usize, String> {
+    let runs = parse_run_count(value)?;
+    if runs < MIN_ADAPTIVE_RUNS {
+        return Err(format!(
+            "run cap must be at least {MIN_ADAPTIVE_RUNS} for adaptive runs"
+        ));
+    }
+    Ok(runs)
+}
+
+/// Parses a target confidence interval half-width, in percent of the mean.
+pub fn parse_ci_pct(value: &str) -> Result<f64, String> {
+    let pct = value
+        .parse::<f64>()
+        .map_err(|_| format!("invalid percentage '{value}'"))?;
+    if !pct.is_finite() || pct <= 0.0 {
+        return Err(format!("percentage '{value}' must be positive"));
+    }
+    Ok(pct)
+}
+
+/// Parses a validator count; the honest threshold is derived from it and needs at least one.
+pub fn parse_validator_count(value: &str) -> Result<usize, String> {
+    let validators = value.parse::<usize>().map_err(|e| e.to_string())?;
+    if validators == 0 {
+        return Err("at least one validator is needed".to_string());
+    }
+    Ok(validators)
+}
+
+/// Parses a duration such as `3s`, `250ms` or `800us` into milliseconds.
+pub fn parse_duration_ms(value: &str) -> Result<f64, String> {
+    let (number, scale) = if let Some(n) = value.strip_suffix("ms") {
+        (n, 1.0)
+    } else if let Some(n) = value.strip_suffix("us") {
+        (n, 1e-3)
+    } else if let Some(n) = value.strip_suffix('s') {
+        (n, 1e3)
+    } else {
+        return Err(format!("duration '{value}' needs a unit (us, ms or s)"));
+    };
+    let number = number
+        .parse::<f64>()
+        .map_err(|_| format!("invalid duration '{value}'"))?;
+    if !number.is_finite() || number <= 0.0 {
+        return Err(format!("duration '{value}' must be positive"));
+    }
+    Ok(number * scale)
+}
+
+/// Where and how the benchmarks ran, written at the top of every result file so that results
+/// shared without context can still be told apart.
+#[derive(Debug, Clone, Default, PartialEq, Serialize)]
+pub struct RunMetadata {
+    /// Free-form label given with `--label`.
+    pub label: Option<String>,
+    pub cpu_model: Option<String>,
+    pub physical_cores: Option<usize>,
+    pub logical_cores: Option<usize>,
+    pub total_memory_kb: Option<u64>,
+    pub os: &'static str,
+    pub arch: &'static str,
+    pub frida_version: &'static str,
+    /// Commit of the source tree the binary was built from, suffixed with `-dirty` if it had
+    /// uncommitted changes. Captured by `build.rs`, empty when built outside a git checkout.
+    pub git_commit: Option<String>,
+    pub concurrent: bool,
+    /// Thread count requested through `RAYON_NUM_THREADS`, honoured by `concurrent` builds.
+    pub rayon_num_threads: Option<String>,
+}
+
+static RUN_METADATA: OnceLock<RunMetadata> = OnceLock::new();
+
+impl RunMetadata {
+    /// Collects the metadata of this machine and build. Anything that cannot be determined, such
+    /// as the CPU model outside of Linux, is left empty.
+    pub fn collect(label: Option<String>) -> Self {
+        let cpuinfo = fs::read_to_string("/proc/cpuinfo").unwrap_or_default();
+        let (cpu_model, physical_cores) = parse_cpuinfo(&cpuinfo);
+        let total_memory_kb = fs::read_to_string("/proc/meminfo")
+            .ok()
+            .and_then(|meminfo| parse_meminfo_total_kb(&meminfo));
+        RunMetadata {
+            label,
+            cpu_model,
+            physical_cores,
+            logical_cores: thread::available_parallelism().ok().map(|n| n.get()),
+            total_memory_kb,
+            os: std::env::consts::OS,
+            arch: std::env::consts::ARCH,
+            frida_version: env!("CARGO_PKG_VERSION"),
+            git_commit: option_env!("FRIDA_GIT_COMMIT").map(str::to_string),
+            concurrent: cfg!(feature = "concurrent"),
+            rayon_num_threads: std::env::var("RAYON_NUM_THREADS").ok(),
+        }
+    }
+
+    /// Records the metadata written to every result file from now on. Only the first call has
+    /// an effect.
+    pub fn record(self) -> &'static RunMetadata {
+        RUN_METADATA.get_or_init(|| self)
+    }
+
+    /// The metadata as `key: value` pairs, skipping anything unknown.
+    pub fn fields(&self) -> Vec<(&'static str, String)> {
+        let mut fields = Vec::new();
+        let mut push = |key, value: Option<String>| {
+            if let Some(value) = value {
+                fields.push((key, value));
+            }
+        };
+        push("label", self.label.clone());
+        push("cpu_model", self.cpu_model.clone());
+        push("physical_cores", self.physical_cores.map(|n| n.to_string()));
+        push("logical_cores", self.logical_cores.map(|n| n.to_string()));
+        push(
+            "total_memory_kb",
+            self.total_memory_kb.map(|kb| kb.to_string()),
+        );
+        push("os", Some(self.os.to_string()));
+        push("arch", Some(self.arch.to_string()));
+        push("frida_version", Some(self.frida_version.to_string()));
+        push("git_commit", self.git_commit.clone());
+        push("concurrent", Some(self.concurrent.to_string()));
+        push("rayon_num_threads", self.rayon_num_threads.clone());
+        fields
+    }
+
+    /// Writes the metadata as CSV comment lines, `# key: value`.
+    pub fn write_csv_comments(&self, out: &mut impl Write) -> std::io::Result<()> {
+        for (key, value) in self.fields() {
+            writeln!(out, "# {key}: {value}")?;
+        }
+        Ok(())
+    }
+}
+
+/// Returns the CPU model and the number of physical cores, counted as distinct
+/// (physical id, core id) pairs, from the contents of `/proc/cpuinfo`.
+fn parse_cpuinfo(cpuinfo: &str) -> (Option<String>, Option<usize>) {
+    let mut model = None;
+    let mut cores = std::collections::BTreeSet::new();
+    for processor in cpuinfo.split("\n\n") {
+        let field = |name: &str| {
+            processor.lines().find_map(|line| {
+                let (key, value) = line.split_once(':')?;
+                (key.trim() == name).then(|| value.trim().to_string())
+            })
+        };
+        if model.is_none() {
+            model = field("model name");
+        }
+        if let (Some(package), Some(core)) = (field("physical id"), field("core id")) {
+            cores.insert((package, core));
+        }
+    }
+    (model, (!cores.is_empty()).then_some(cores.len()))
+}
+
+/// Returns `MemTotal` in KB from the contents of `/proc/meminfo`.
+fn parse_meminfo_total_kb(meminfo: &str) -> Option<u64> {
+    meminfo
+        .lines()
+        .find_map(|line| line.strip_prefix("MemTotal:"))?
+        .split_whitespace()
+        .next()?
+        .parse()
+        .ok()
+}
+
 /// Creates output directory if it doesn't exist
 pub fn ensure_output_dir(output_path: &str) -> std::io::Result<()> {
     if let Some(parent) = Path::new(output_path).parent() {
@@ -62,6 +543,9 @@ where
     ensure_output_dir(output_path)?;
 
     let mut file = fs::File::create(output_path)?;
+    if let Some(metadata) = RUN_METADATA.get() {
+        metadata.write_csv_comments(&mut file)?;
+    }
     writeln!(file, "{header}")?;
 
     for result in results {
@@ -73,6 +557,110 @@ where
     Ok(())
 }
 
+/// First line of a JSON results file when run metadata has been recorded.
+#[derive(Serialize)]
+struct JsonMetadata<'a> {
+    schema_version: u32,
+    metadata: &'a RunMetadata,
+}
+
+/// A JSON result record, tagged with the schema version it was written with.
+#[derive(Serialize)]
+struct JsonRecord<'a, T> {
+    schema_version: u32,
+    #[serde(flatten)]
+    result: &'a T,
+}
+
+/// Writes results as JSON Lines, one record per line, flushing after every record so that a sweep
+/// that dies partway through keeps everything finished before it. If run metadata has been
+/// recorded, it comes first, in a line of its own under a `metadata` key.
+pub struct JsonLinesWriter {
+    file: fs::File,
+}
+
+impl JsonLinesWriter {
+    pub fn create(output_path: &str) -> std::io::Result<Self> {
+        Self::create_with_metadata(output_path, RUN_METADATA.get())
+    }
+
+    fn create_with_metadata(
+        output_path: &str,
+        metadata: Option<&RunMetadata>,
+    ) -> std::io::Result<Self> {
+        ensure_output_dir(output_path)?;
+        let mut file = fs::File::create(output_path)?;
+        if let Some(metadata) = metadata {
+            let line = JsonMetadata {
+                schema_version: RESULTS_SCHEMA_VERSION,
+                metadata,
+            };
+            serde_json::to_writer(&mut file, &line)?;
+            writeln!(file)?;
+        }
+        Ok(JsonLinesWriter { file })
+    }
+
+    pub fn append<T: Serialize>(&mut self, result: &T) -> std::io::Result<()> {
+        let record = JsonRecord {
+            schema_version: RESULTS_SCHEMA_VERSION,
+            result,
+        };
+        serde_json::to_writer(&mut self.file, &record)?;
+        writeln!(self.file)?;
+        self.file.flush()
+    }
+}
+
+/// Computes the time a configuration spent outside its timed phases.
+///
+/// Returns the average overhead per run in milliseconds and the overhead as a percentage of the
+/// total wall time.
+pub fn harness_overhead(wall_time: Duration, timed: Duration, runs: usize) -> (f64, f64) {
+    let overhead = wall_time.saturating_sub(timed);
+    let overhead_pct = if wall_time.is_zero() {
+        0.0
+    } else {
+        overhead.as_secs_f64() / wall_time.as_secs_f64() * 100.0
+    };
+    (overhead.as_secs_f64() * 1000.0 / runs as f64, overhead_pct)
+}
+
+/// Prints a warning when a configuration spent more than [`OVERHEAD_WARN_PCT`] of its wall time
+/// outside timed phases.
+pub fn warn_on_overhead(label: &str, overhead_pct: f64) {
+    if overhead_pct > OVERHEAD_WARN_PCT {
+        println!("Warning: {label} spent {overhead_pct:.1}% of its wall time outside timed phases");
+    }
+}
+
+/// Prints the harness overhead over all configurations of a sweep.
+pub fn print_overhead_summary(overhead_pcts: &[f64]) {
+    if overhead_pcts.is_empty() {
+        return;
+    }
+    let mean = overhead_pcts.iter().sum::<f64>() / overhead_pcts.len() as f64;
+    let max = overhead_pcts.iter().copied().fold(0.0, f64::max);
+    let flagged = overhead_pcts
+        .iter()
+        .filter(|&&pct| pct > OVERHEAD_WARN_PCT)
+        .count();
+    println!(
+        "Harness overhead: mean {mean:.1}%, max {max:.1}%, {flagged} configurations above {OVERHEAD_WARN_PCT}%"
+    );
+}
+
+/// Prints the calibrated timer overhead and how many configurations had low-confidence phases.
+pub fn print_low_confidence_summary(flagged: usize, timer_overhead: Duration) {
+    println!("Timer overhead: {} ns", timer_overhead.as_nanos());
+    if flagged > 0 {
+        println!(
+            "{flagged} configurations have phases within {}x of the timer overhead (see low_confidence_phases)",
+            stats::LOW_CONFIDENCE_FACTOR
+        );
+    }
+}
+
 pub mod field_names {
     pub const F64: &str = "f64";
     pub const F128: &str = "f128";
@@ -80,5 +668,532 @@ pub mod field_names {
 
 pub type F64Element = f64::BaseElement;
 pub type F128Element = f128::BaseElement;
-pub type Blake3F64 = winter_crypto::hashers::Blake3_256<F64Element>;
-pub type Blake3F128 = winter_crypto::hashers::Blake3_256<F128Element>;
+pub type Blake3F64 = Blake3_256<F64Element>;
+pub type Blake3F128 = Blake3_256<F128Element>;
+pub type Sha3F64 = Sha3_256<F64Element>;
+pub type Sha3F128 = Sha3_256<F128Element>;
+
+/// Hash function used for the Merkle trees and the Fiat-Shamir channel of a benchmark.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub enum HashFunction {
+    #[default]
+    Blake3,
+    Sha3,
+}
+
+impl HashFunction {
+    pub const ALL: [HashFunction; 2] = [HashFunction::Blake3, HashFunction::Sha3];
+
+    pub fn name(self) -> &'static str {
+        match self {
+            HashFunction::Blake3 => "blake3",
+            HashFunction::Sha3 => "sha3",
+        }
+    }
+}
+
+/// Ties a hasher type to its [`HashFunction`], so that results can name the hash they were
+/// produced with. Field-based hashers fit as well, as hashers take field elements as input.
+pub trait NamedHasher {
+    const HASH: HashFunction;
+}
+
+impl<B: StarkField> NamedHasher for Blake3_256<B> {
+    const HASH: HashFunction = HashFunction::Blake3;
+}
+
+impl<B: StarkField> NamedHasher for Sha3_256<B> {
+    const HASH: HashFunction = HashFunction::Sha3;
+}
+
+/// Parses a `--hash` value by name, e.g. `blake3`.
+pub fn parse_hash_function(value: &str) -> Result<HashFunction, String> {
+    HashFunction::ALL
+        .into_iter()
+        .find(|hash| hash.name() == value)
+        .ok_or_else(|| {
+            let names = HashFunction::ALL.map(HashFunction::name);
+            format!(
+                "unknown hash '{value}', expected one of: {}",
+                names.join(", ")
+            )
+        })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use frida_poc::core::data::build_evaluations_from_data;
+    use winter_crypto::{Digest, Hasher, MerkleTree};
+
+    #[test]
+    fn test_seeded_input_is_reproducible() {
+        let source = InputSource::Seeded(7);
+        assert_eq!(source.blob(3, 100), source.blob(3, 100));
+        assert_ne!(source.blob(3, 100), source.blob(4, 100));
+        assert_ne!(source.blob(3, 100), InputSource::Seeded(8).blob(3, 100));
+        assert_eq!(InputSource::Random.blob(0, 100).len(), 100);
+    }
+
+    #[test]
+    fn test_json_lines_writer() {
+        #[derive(Serialize)]
+        struct Row {
+            name: &'static str,
+            time_ms: f64,
+        }
+
+        let path = std::env::temp_dir().join("frida-bench-json-lines/results.jsonl");
+        let path = path.to_str().unwrap();
+        let mut writer = JsonLinesWriter::create(path).unwrap();
+        writer
+            .append(&Row {
+                name: "a",
+                time_ms: 1.5,
+            })
+            .unwrap();
+        // Earlier records are on disk before the writer is dropped
+        assert_eq!(fs::read_to_string(path).unwrap().lines().count(), 1);
+        writer
+            .append(&Row {
+                name: "b",
+                time_ms: 2.0,
+            })
+            .unwrap();
+        drop(writer);
+
+        let lines = fs::read_to_string(path)
+            .unwrap()
+            .lines()
+            .map(|line| serde_json::from_str::<serde_json::Value>(line).unwrap())
+            .collect::<Vec<_>>();
+        assert_eq!(lines.len(), 2);
+        assert_eq!(lines[0]["schema_version"], RESULTS_SCHEMA_VERSION);
+        assert_eq!(lines[0]["name"], "a");
+        assert_eq!(lines[1]["time_ms"], 2.0);
+        fs::remove_dir_all(Path::new(path).parent().unwrap()).unwrap();
+    }
+
+    #[test]
+    fn test_json_lines_writer_starts_with_metadata() {
+        let metadata = RunMetadata {
+            label: Some("laptop".to_string()),
+            logical_cores: Some(8),
+            os: "linux",
+            ..Default::default()
+        };
+        let path = std::env::temp_dir().join("frida-bench-json-metadata/results.jsonl");
+        let path = path.to_str().unwrap();
+        let mut writer = JsonLinesWriter::create_with_metadata(path, Some(&metadata)).unwrap();
+        writer.append(&serde_json::json!({ "name": "a" })).unwrap();
+        drop(writer);
+
+        let lines = fs::read_to_string(path)
+            .unwrap()
+            .lines()
+            .map(|line| serde_json::from_str::<serde_json::Value>(line).unwrap())
+            .collect::<Vec<_>>();
+        assert_eq!(lines.len(), 2);
+        assert_eq!(lines[0]["schema_version"], RESULTS_SCHEMA_VERSION);
+        assert_eq!(lines[0]["metadata"]["label"], "laptop");
+        assert_eq!(lines[0]["metadata"]["logical_cores"], 8);
+        assert_eq!(lines[0]["metadata"]["cpu_model"], serde_json::Value::Null);
+        assert_eq!(lines[1]["name"], "a");
+        fs::remove_dir_all(Path::new(path).parent().unwrap()).unwrap();
+    }
+
+    #[test]
+    fn test_metadata_csv_comments() {
+        let metadata = RunMetadata {
+            label: Some("server run".to_string()),
+            cpu_model: Some("AMD EPYC 7763 64-Core Processor".to_string()),
+            os: "linux",
+            arch: "x86_64",
+            frida_version: "0.1.0",
+            ..Default::default()
+        };
+        let mut out = Vec::new();
+        metadata.write_csv_comments(&mut out).unwrap();
+        assert_eq!(
+            String::from_utf8(out).unwrap(),
+            "# label: server run\n\
+             # cpu_model: AMD EPYC 7763 64-Core Processor\n\
+             # os: linux\n\
+             # arch: x86_64\n\
+             # frida_version: 0.1.0\n\
+             # concurrent: false\n"
+        );
+    }
+
+    #[test]
+    fn test_parse_cpuinfo() {
+        // Two packages of two cores each, with hyper-threading
+        let cpuinfo = (0..8)
+            .map(|i| {
+                format!(
+                    "processor\t: {i}\nmodel name\t: Test CPU @ 3.00GHz\nphysical id\t: {}\ncore id\t\t: {}\n",
+                    i / 4,
+                    i % 2
+                )
+            })
+            .collect::<Vec<_>>()
+            .join("\n");
+        assert_eq!(
+            parse_cpuinfo(&cpuinfo),
+            (Some("Test CPU @ 3.00GHz".to_string()), Some(4))
+        );
+        // Some ARM kernels report neither a model name nor core ids
+        assert_eq!(
+            parse_cpuinfo("processor\t: 0\nBogoMIPS\t: 48.00\n"),
+            (None, None)
+        );
+        assert_eq!(parse_cpuinfo(""), (None, None));
+
+        assert_eq!(
+            parse_meminfo_total_kb("MemTotal:       16318412 kB\nMemFree:         1234 kB\n"),
+            Some(16318412)
+        );
+        assert_eq!(parse_meminfo_total_kb("MemFree: 1 kB\n"), None);
+    }
+
+    #[test]
+    fn test_harness_overhead() {
+        let (overhead_ms, overhead_pct) =
+            harness_overhead(Duration::from_millis(100), Duration::from_millis(75), 5);
+        assert!((overhead_ms - 5.0).abs() < 1e-9);
+        assert!((overhead_pct - 25.0).abs() < 1e-9);
+    }
+
+    #[test]
+    fn test_harness_overhead_timed_exceeds_wall() {
+        // Timers can accumulate slightly more than the enclosing wall clock due to resolution.
+        let (overhead_ms, overhead_pct) =
+            harness_overhead(Duration::from_millis(10), Duration::from_millis(11), 1);
+        assert_eq!(overhead_ms, 0.0);
+        assert_eq!(overhead_pct, 0.0);
+    }
+
+    #[test]
+    fn test_harness_overhead_zero_wall_time() {
+        let (_, overhead_pct) = harness_overhead(Duration::ZERO, Duration::ZERO, 1);
+        assert_eq!(overhead_pct, 0.0);
+    }
+
+    #[test]
+    fn test_sweep_totals() {
+        let totals = [
+            SweepTotals {
+                data_bytes: 512 * 1024 * 1024,
+                proofs: 40,
+                verifications: 30,
+            },
+            SweepTotals {
+                data_bytes: 512 * 1024 * 1024,
+                proofs: 2,
+                verifications: 0,
+            },
+        ]
+        .into_iter()
+        .fold(SweepTotals::default(), |acc, t| acc + t);
+
+        assert_eq!(
+            totals,
+            SweepTotals {
+                data_bytes: 1024 * 1024 * 1024,
+                proofs: 42,
+                verifications: 30,
+            }
+        );
+        assert_eq!(
+            totals.summary(Duration::from_secs(90)),
+            "Sweep totals: 1.000 GB of data processed, 42 proofs generated, 30 verifications performed in 90.0 s"
+        );
+    }
+
+    #[test]
+    fn test_domain_geometry() {
+        let (log2_domain_size, extension_factor) = domain_geometry(1024, 300);
+        assert_eq!(log2_domain_size, 10);
+        assert!((extension_factor - 1024.0 / 300.0).abs() < 1e-9);
+        assert_eq!(domain_geometry(8, 8), (3, 1.0));
+    }
+
+    #[test]
+    #[should_panic(expected = "is not a power of two")]
+    fn test_domain_geometry_rejects_non_power_of_two() {
+        domain_geometry(1000, 10);
+    }
+
+    #[test]
+    fn test_data_footprint() {
+        // A single byte still needs the length prefix and the smallest domain
+        let footprint = DataFootprint::new::<F64Element>(1, 8, 2);
+        assert_eq!(footprint.encoded_size_bytes, 16);
+        assert_eq!(footprint.padded_size_bytes, 32);
+        assert_eq!(footprint.padding_overhead_pct, 3100.0);
+        let footprint = DataFootprint::new::<F128Element>(1, 8, 2);
+        assert_eq!(footprint.encoded_size_bytes, 16);
+        assert_eq!(footprint.padded_size_bytes, 64);
+
+        // 31 and 32 bytes both take 6 f64 elements, padded to 8
+        for (data_size, overhead_pct) in [(31, 106.45), (32, 100.0)] {
+            let footprint = DataFootprint::new::<F64Element>(data_size, 16, 2);
+            assert_eq!(footprint.data_size_bytes, data_size);
+            assert_eq!(footprint.encoded_size_bytes, 48);
+            assert_eq!(footprint.padded_size_bytes, 64);
+            assert!((footprint.padding_overhead_pct - overhead_pct).abs() < 0.01);
+        }
+
+        // 7160 bytes fill exactly 1024 f64 elements; one more byte doubles the polynomial
+        let full = DataFootprint::new::<F64Element>(7160, 2048, 2);
+        assert_eq!(full.encoded_size_bytes, full.padded_size_bytes);
+        assert_eq!(
+            (
+                full.systematic_evaluations,
+                full.padding_evaluations,
+                full.parity_evaluations
+            ),
+            (1024, 0, 1024)
+        );
+        let over = DataFootprint::new::<F64Element>(7161, 4096, 2);
+        assert_eq!(over.encoded_size_bytes, 1025 * 8);
+        assert_eq!(over.padded_size_bytes, 2 * full.padded_size_bytes);
+        assert!(over.padding_overhead_pct > 100.0 && full.padding_overhead_pct < 15.0);
+    }
+
+    #[test]
+    fn test_evaluation_counts() {
+        let counts = |footprint: DataFootprint| {
+            (
+                footprint.systematic_evaluations,
+                footprint.padding_evaluations,
+                footprint.parity_evaluations,
+            )
+        };
+        let footprint = |data_size, batch_size, blowup_factor| {
+            let domain_size =
+                required_domain_size::<F64Element>(data_size, batch_size, blowup_factor);
+            DataFootprint::new::<F64Element>(data_size, domain_size, blowup_factor)
+        };
+        // The minimum domain size pads two elements to four
+        assert_eq!(counts(footprint(1, 1, 2)), (2, 2, 4));
+        // Data filling the polynomial exactly leaves no padding
+        assert_eq!(counts(footprint(7160, 1, 4)), (1024, 0, 3072));
+        // One element past a power of two pads nearly a whole polynomial, batched or not
+        assert_eq!(counts(footprint(7161, 4, 2)), (1025, 1023, 2048));
+        assert_eq!(counts(footprint(7161, 1, 8)), (1025, 1023, 14336));
+        let wide = DataFootprint::new::<F128Element>(100, 64, 8);
+        assert_eq!(counts(wide), (8, 0, 56));
+
+        // The systematic positions hold the encoded data and then the zero padding
+        let evaluations = build_evaluations_from_data::<F64Element>(&[0xff; 10], 8, 2).unwrap();
+        assert_eq!(
+            counts(DataFootprint::new::<F64Element>(10, 8, 2)),
+            (3, 1, 4)
+        );
+        assert!(evaluations[..6]
+            .iter()
+            .step_by(2)
+            .all(|e| *e != F64Element::ZERO));
+        assert_eq!(evaluations[6], F64Element::ZERO);
+    }
+
+    /// Root of a Merkle tree over the hashes of the bytes 0 to 3.
+    fn merkle_root_hex<H: Hasher>() -> String {
+        let leaves = (0u8..4).map(|i| H::hash(&[i])).collect();
+        let tree = MerkleTree::<H>::new(leaves).unwrap();
+        tree.root().as_bytes().map(|b| format!("{b:02x}")).concat()
+    }
+
+    #[test]
+    fn test_hash_merkle_roots() {
+        assert_eq!(
+            merkle_root_hex::<Blake3F64>(),
+            "1f7f54f7a6d7440e0e8a681ee8aff25664d4354b7a6afd0fbde51af4246f908b"
+        );
+        assert_eq!(
+            merkle_root_hex::<Sha3F64>(),
+            "e349c4a7f57723a42f0869723644e28b2c0b03bb59585fc4765bebd708f19526"
+        );
+        // The base field does not enter byte hashing
+        assert_eq!(
+            merkle_root_hex::<Blake3F128>(),
+            merkle_root_hex::<Blake3F64>()
+        );
+        assert_eq!(merkle_root_hex::<Sha3F128>(), merkle_root_hex::<Sha3F64>());
+    }
+
+    #[test]
+    fn test_parse_hash_function() {
+        for hash in HashFunction::ALL {
+            assert_eq!(parse_hash_function(hash.name()), Ok(hash));
+        }
+        assert_eq!(Blake3F128::HASH, HashFunction::Blake3);
+        assert_eq!(Sha3F64::HASH, HashFunction::Sha3);
+        let err = parse_hash_function("keccak").unwrap_err();
+        assert_eq!(err, "unknown hash 'keccak', expected one of: blake3, sha3");
+    }
+
+    #[test]
+    fn test_required_domain_size() {
+        // 1024 f64 elements, doubled by the blowup factor
+        assert_eq!(required_domain_size::<F64Element>(7160, 1, 2), 2048);
+        assert_eq!(required_domain_size::<F64Element>(7161, 1, 2), 4096);
+        assert_eq!(required_domain_size::<F64Element>(7160, 4, 2), 2048);
+        assert_eq!(required_domain_size::<F128Element>(1, 1, 2), 8);
+    }
+
+    #[test]
+    fn test_check_domain_fits_at_the_boundary() {
+        let max_elements = constants::MAX_DOMAIN_SIZE / 2;
+        for batch_size in [1, 4] {
+            let largest = max_elements * 7 - 8;
+            assert_eq!(
+                check_domain_fits::<F64Element>(largest, batch_size, 2),
+                Ok(())
+            );
+            let err = check_domain_fits::<F64Element>(largest + 1, batch_size, 2).unwrap_err();
+            assert_eq!(
+                err,
+                format!(
+                    "domain of 2^25 exceeds the maximum of 2^24; at most {largest} bytes per blob fit with blowup factor 2"
+                )
+            );
+        }
+        let largest = max_elements / 4 * 15 - 8;
+        assert!(check_domain_fits::<F128Element>(largest, 1, 8).is_ok());
+        assert!(check_domain_fits::<F128Element>(largest + 1, 1, 8).is_err());
+    }
+
+    #[test]
+    fn test_parse_data_size() {
+        assert_eq!(parse_data_size("1"), Ok(1));
+        assert_eq!(parse_data_size("33"), Ok(33));
+        assert!(parse_data_size("0").is_err());
+        assert!(parse_data_size("-1").is_err());
+        assert!(parse_data_size("abc").is_err());
+    }
+
+    #[test]
+    fn test_standard_data_sizes_fill_encoded_sizes() {
+        assert_eq!(
+            get_standard_data_sizes::<F64Element>()[0],
+            128 * 1024 / 8 * 7 - 8
+        );
+        assert_eq!(
+            get_standard_data_sizes::<F128Element>()[0],
+            128 * 1024 / 16 * 15 - 8
+        );
+        assert_eq!(
+            data_size_for_encoded_size::<F64Element>(MIN_ENCODED_SIZE),
+            6
+        );
+        assert_eq!(
+            data_size_for_encoded_size::<F128Element>(MIN_ENCODED_SIZE),
+            7
+        );
+    }
+
+    #[test]
+    fn test_parse_encoded_size() {
+        assert_eq!(parse_encoded_size("4096"), Ok(4096));
+        assert_eq!(parse_encoded_size("128K"), Ok(128 * 1024));
+        assert_eq!(parse_encoded_size("2M"), Ok(2 * 1024 * 1024));
+        assert_eq!(parse_encoded_size("16"), Ok(16));
+        assert!(parse_encoded_size("15").is_err());
+        assert!(parse_encoded_size("0K").is_err());
+        assert!(parse_encoded_size("1.5M").is_err());
+        assert!(parse_encoded_size("K").is_err());
+        assert!(parse_encoded_size("99999999999999999999M").is_err());
+    }
+
+    #[test]
+    fn test_parse_fri_option() {
+        assert_eq!(parse_fri_option("2:4:2"), Ok((2, 4, 2)));
+        assert_eq!(parse_fri_option("8:16:256"), Ok((8, 16, 256)));
+        assert!(parse_fri_option("2:4").is_err());
+        assert!(parse_fri_option("2:4:2:1").is_err());
+        assert!(parse_fri_option("1:4:2").is_err());
+        assert!(parse_fri_option("3:4:2").is_err());
+        assert!(parse_fri_option("2:3:2").is_err());
+        assert!(parse_fri_option("2:x:2").is_err());
+    }
+
+    #[test]
+    fn test_parse_sweep_batch_size() {
+        assert_eq!(parse_sweep_batch_size("4"), Ok(4));
+        assert!(parse_sweep_batch_size("1").is_err());
+        assert!(parse_sweep_batch_size("0").is_err());
+    }
+
+    #[test]
+    fn test_parse_run_count() {
+        assert_eq!(parse_run_count("100"), Ok(100));
+        assert!(parse_run_count("0").is_err());
+        assert!(parse_run_count("ten").is_err());
+    }
+
+    #[test]
+    fn test_parse_max_runs() {
+        assert_eq!(parse_max_runs("100"), Ok(100));
+        assert_eq!(parse_max_runs("3"), Ok(MIN_ADAPTIVE_RUNS));
+        assert!(parse_max_runs("2").is_err());
+        assert!(parse_max_runs("0").is_err());
+    }
+
+    #[test]
+    fn test_parse_ci_pct() {
+        assert_eq!(parse_ci_pct("5"), Ok(5.0));
+        assert_eq!(parse_ci_pct("0.5"), Ok(0.5));
+        for bad in ["0", "-1", "NaN", "inf", "five"] {
+            assert!(parse_ci_pct(bad).is_err(), "{bad}");
+        }
+    }
+
+    #[test]
+    fn test_parse_validator_count() {
+        assert_eq!(parse_validator_count("1"), Ok(1));
+        assert!(parse_validator_count("0").is_err());
+        assert!(parse_validator_count("-4").is_err());
+    }
+
+    #[test]
+    fn test_parse_duration_ms() {
+        assert_eq!(parse_duration_ms("3s"), Ok(3000.0));
+        assert_eq!(parse_duration_ms("250ms"), Ok(250.0));
+        assert_eq!(parse_duration_ms("0.5s"), Ok(500.0));
+        assert_eq!(parse_duration_ms("800us"), Ok(0.8));
+        assert!(parse_duration_ms("3").is_err());
+        assert!(parse_duration_ms("0s").is_err());
+        assert!(parse_duration_ms("-1ms").is_err());
+        assert!(parse_duration_ms("fast s").is_err());
+    }
+
+    #[test]
+    fn test_fixed_run_policy() {
+        let policy = RunPolicy::Fixed(3);
+        assert!(policy.needs_more_runs(&[1.0, 1.0]));
+        assert!(!policy.needs_more_runs(&[1.0, 1.0, 1.0]));
+    }
+
+    #[test]
+    fn test_adaptive_run_policy_stops_when_ci_is_tight() {
+        let policy = RunPolicy::Adaptive {
+            ci_pct: 5.0,
+            max_runs: 100,
+        };
+        assert!(policy.needs_more_runs(&[10.0, 10.0]));
+        assert!(!policy.needs_more_runs(&[10.0, 10.0, 10.0]));
+        assert!(policy.needs_more_runs(&[5.0, 15.0, 10.0]));
+    }
+
+    #[test]
+    fn test_adaptive_run_policy_respects_max_runs() {
+        let policy = RunPolicy::Adaptive {
+            ci_pct: 0.1,
+            max_runs: 4,
+        };
+        assert!(policy.needs_more_runs(&[1.0, 9.0, 1.0]));
+        assert!(!policy.needs_more_runs(&[1.0, 9.0, 1.0, 9.0]));
+    }
+}
@@ -0,0 +1,403 @@
+use serde::Serialize;
+use std::{collections::BTreeMap, fs};
+
+use crate::{
+    common::JsonLinesWriter,
+    stats::{self, mann_whitney_u},
+};
+
+/// Per-run times of a profile, in milliseconds, by configuration and then phase.
+type Runs = BTreeMap<String, BTreeMap<String, Vec<f64>>>;
+
+/// Columns of a profile that describe a run or its output rather than the configuration.
+const OUTPUT_COLUMNS: [&str; 3] = ["run", "positions", "artifact_hash"];
+
+#[derive(Debug, Default, PartialEq)]
+struct Profile {
+    runs: Runs,
+    /// Hash of the produced artifacts by configuration, recorded for seeded runs only.
+    artifact_hashes: BTreeMap<String, String>,
+}
+
+/// Reads a per-run profile such as `frida custom --profile-runs` writes. Columns ending in
+/// `_ms` are phases, `artifact_hash` is kept per configuration, `run` and `positions` are
+/// ignored, and every other column identifies the configuration. Comment lines, which hold the
+/// run metadata, are skipped.
+fn parse_runs(path: &str, contents: &str) -> Result<Profile, String> {
+    let mut lines = contents
+        .lines()
+        .enumerate()
+        .filter(|(_, line)| !line.trim().is_empty() && !line.starts_with('#'));
+    let header = lines
+        .next()
+        .ok_or_else(|| format!("{path} is empty"))?
+        .1
+        .split(',')
+        .collect::<Vec<_>>();
+    if !header.iter().any(|column| column.ends_with("_ms")) {
+        return Err(format!("{path} has no per-run phase columns ending in _ms"));
+    }
+
+    let mut profile = Profile::default();
+    for (i, line) in lines {
+        let values = line.split(',').collect::<Vec<_>>();
+        if values.len() != header.len() {
+            return Err(format!(
+                "{path} line {}: expected {} columns, found {}",
+                i + 1,
+                header.len(),
+                values.len()
+            ));
+        }
+        let configuration = header
+            .iter()
+            .zip(&values)
+            .filter(|(column, _)| !OUTPUT_COLUMNS.contains(column) && !column.ends_with("_ms"))
+            .map(|(column, value)| format!("{column}={value}"))
+            .collect::<Vec<_>>()
+            .join(" ");
+        let phases = profile.runs.entry(configuration.clone()).or_default();
+        for (column, value) in header.iter().zip(&values) {
+            if *column == "artifact_hash" && !value.is_empty() {
+                profile
+                    .artifact_hashes
+                    .insert(configuration.clone(), value.to_string());
+            } else if let Some(phase) = column.strip_suffix("_ms") {
+                let ms = value
+                    .parse::<f64>()
+                    .map_err(|_| format!("{path} line {}: invalid {column} '{value}'", i + 1))?;
+                phases.entry(phase.to_string()).or_default().push(ms);
+            }
+        }
+    }
+    Ok(profile)
+}
+
+/// Returns each configuration that has an artifact hash in both profiles, but a different one,
+/// with the old and the new hash.
+fn changed_artifacts<'a>(old: &'a Profile, new: &'a Profile) -> Vec<(&'a str, &'a str, &'a str)> {
+    old.artifact_hashes
+        .iter()
+        .filter_map(|(configuration, old_hash)| {
+            let new_hash = new.artifact_hashes.get(configuration)?;
+            (old_hash != new_hash).then_some((
+                configuration.as_str(),
+                old_hash.as_str(),
+                new_hash.as_str(),
+            ))
+        })
+        .collect()
+}
+
+/// Reads the `# key: value` run metadata at the top of a result file.
+fn parse_metadata(contents: &str) -> Vec<(&str, &str)> {
+    contents
+        .lines()
+        .map_while(|line| line.strip_prefix('#'))
+        .filter_map(|line| line.split_once(':'))
+        .map(|(key, value)| (key.trim(), value.trim()))
+        .collect()
+}
+
+/// Comparison of one phase of one configuration between two profiles.
+#[derive(Debug, Serialize)]
+struct PhaseComparison {
+    configuration: String,
+    phase: String,
+    old_runs: usize,
+    new_runs: usize,
+    old_p50_ms: f64,
+    old_p90_ms: f64,
+    old_p99_ms: f64,
+    new_p50_ms: f64,
+    new_p90_ms: f64,
+    new_p99_ms: f64,
+    mann_whitney_u: f64,
+    p_value: f64,
+    /// Rank-biserial correlation; positive when the new runs tend to be slower.
+    effect_size: f64,
+    significant: bool,
+}
+
+/// Compares every phase of every configuration found in both profiles. Returns the comparisons
+/// and the configurations found in only one of them.
+fn compare_runs(old: &Runs, new: &Runs, alpha: f64) -> (Vec<PhaseComparison>, Vec<String>) {
+    let mut comparisons = Vec::new();
+    let mut unmatched = Vec::new();
+    for (configuration, old_phases) in old {
+        let Some(new_phases) = new.get(configuration) else {
+            unmatched.push(format!("{configuration} (old only)"));
+            continue;
+        };
+        for (phase, old_ms) in old_phases {
+            let Some(new_ms) = new_phases.get(phase) else {
+                continue;
+            };
+            let test = mann_whitney_u(old_ms, new_ms);
+            comparisons.push(PhaseComparison {
+                configuration: configuration.clone(),
+                phase: phase.clone(),
+                old_runs: old_ms.len(),
+                new_runs: new_ms.len(),
+                old_p50_ms: stats::percentile(old_ms, 50.0),
+                old_p90_ms: stats::percentile(old_ms, 90.0),
+                old_p99_ms: stats::percentile(old_ms, 99.0),
+                new_p50_ms: stats::percentile(new_ms, 50.0),
+                new_p90_ms: stats::percentile(new_ms, 90.0),
+                new_p99_ms: stats::percentile(new_ms, 99.0),
+                mann_whitney_u: test.u,
+                p_value: test.p_value,
+                effect_size: test.effect_size,
+                significant: test.p_value < alpha,
+            });
+        }
+    }
+    unmatched.extend(
+        new.keys()
+            .filter(|configuration| !old.contains_key(*configuration))
+            .map(|configuration| format!("{configuration} (new only)")),
+    );
+    (comparisons, unmatched)
+}
+
+/// Parses a significance level, which must lie strictly between 0 and 1.
+pub fn parse_alpha(value: &str) -> Result<f64, String> {
+    let alpha = value.parse::<f64>().map_err(|e| e.to_string())?;
+    if !(alpha > 0.0 && alpha < 1.0) {
+        return Err(format!(
+            "significance level {value} must be between 0 and 1"
+        ));
+    }
+    Ok(alpha)
+}
+
+pub struct CompareConfig<'a> {
+    pub old_path: &'a str,
+    pub new_path: &'a str,
+    /// Significance level of the per-phase tests.
+    pub alpha: f64,
+    pub json_output: Option<&'a str>,
+}
+
+/// Compares two per-run profiles phase by phase and prints which distributions shifted.
+pub fn run_compare(config: CompareConfig) -> Result<(), String> {
+    let read =
+        |path: &str| fs::read_to_string(path).map_err(|e| format!("cannot read {path}: {e}"));
+    let old_contents = read(config.old_path)?;
+    let new_contents = read(config.new_path)?;
+    let old = parse_runs(config.old_path, &old_contents)?;
+    let new = parse_runs(config.new_path, &new_contents)?;
+    let (comparisons, unmatched) = compare_runs(&old.runs, &new.runs, config.alpha);
+    if comparisons.is_empty() {
+        return Err("the profiles have no configuration and phase in common".to_string());
+    }
+
+    let old_metadata = parse_metadata(&old_contents);
+    let new_metadata = parse_metadata(&new_contents);
+    for (path, metadata) in [
+        (config.old_path, &old_metadata),
+        (config.new_path, &new_metadata),
+    ] {
+        if !metadata.is_empty() {
+            let fields = metadata
+                .iter()
+                .map(|(key, value)| format!("{key}={value}"))
+                .collect::<Vec<_>>();
+            println!("{path}: {}", fields.join(", "));
+        }
+    }
+    let different_machines = ["cpu_model", "logical_cores"].into_iter().any(|key| {
+        let value = |metadata: &[(&str, &str)]| {
+            metadata
+                .iter()
+                .find(|(k, _)| *k == key)
+                .map(|(_, v)| v.to_string())
+        };
+        let (old, new) = (value(&old_metadata), value(&new_metadata));
+        old.is_some() && new.is_some() && old != new
+    });
+    if different_machines {
+        println!("Warning: the profiles were recorded on different machines");
+    }
+
+    println!(
+        "Comparing {} with {} (Mann-Whitney U, two-sided, alpha {}):",
+        config.old_path, config.new_path, config.alpha
+    );
+    for c in &comparisons {
+        println!(
+            "  {}{} {}: p50/p90/p99 {:.3}/{:.3}/{:.3} -> {:.3}/{:.3}/{:.3} ms, p={:.4}, effect {:+.2}{}",
+            if c.significant { "* " } else { "  " },
+            c.configuration,
+            c.phase,
+            c.old_p50_ms,
+            c.old_p90_ms,
+            c.old_p99_ms,
+            c.new_p50_ms,
+            c.new_p90_ms,
+            c.new_p99_ms,
+            c.p_value,
+            c.effect_size,
+            match (c.significant, c.effect_size > 0.0) {
+                (false, _) => "",
+                (true, true) => " (slower)",
+                (true, false) => " (faster)",
+            }
+        );
+    }
+    let shifted = comparisons.iter().filter(|c| c.significant).count();
+    println!(
+        "{shifted} of {} phases shifted significantly",
+        comparisons.len()
+    );
+    for configuration in &unmatched {
+        println!("  not compared: {configuration}");
+    }
+    // The same seed producing different artifacts is a change in the encoder's output, worth
+    // investigating even when none of the times moved
+    for (configuration, old_hash, new_hash) in changed_artifacts(&old, &new) {
+        let timing_shifted = comparisons
+            .iter()
+            .any(|c| c.configuration == configuration && c.significant);
+        println!(
+            "  artifacts changed: {configuration}, {old_hash} -> {new_hash}{}",
+            if timing_shifted {
+                ""
+            } else {
+                " (timing unchanged)"
+            }
+        );
+    }
+
+    if let Some(path) = config.json_output {
+        let mut writer =
+            JsonLinesWriter::create(path).map_err(|e| format!("cannot create {path}: {e}"))?;
+        for comparison in &comparisons {
+            writer
+                .append(comparison)
+                .map_err(|e| format!("cannot write {path}: {e}"))?;
+        }
+        println!("Comparisons written to {path}");
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const OLD: &str = "field_type,run,proof_32_ms,verification_32_ms
+f64,0,1.0,0.5
+f64,1,1.1,0.5
+f64,2,1.2,0.6
+f64,3,1.3,0.6
+f128,0,2.0,1.0
+";
+
+    #[test]
+    fn test_parse_runs_groups_by_configuration() {
+        let runs = parse_runs("old.csv", OLD).unwrap().runs;
+        assert_eq!(runs.len(), 2);
+        assert_eq!(runs["field_type=f64"]["proof_32"], vec![1.0, 1.1, 1.2, 1.3]);
+        assert_eq!(runs["field_type=f128"]["verification_32"], vec![1.0]);
+
+        let err = parse_runs("bad.csv", "field_type,run,proof_32_ms\nf64,0\n").unwrap_err();
+        assert!(
+            err.starts_with("bad.csv line 2: expected 3 columns"),
+            "{err}"
+        );
+        assert!(parse_runs("empty.csv", "").is_err());
+        assert!(parse_runs("comments.csv", "# label: x\n").is_err());
+        assert!(parse_runs("none.csv", "field_type,run\nf64,0\n").is_err());
+    }
+
+    #[test]
+    fn test_metadata_comments_are_skipped() {
+        let contents = format!("# label: laptop\n# cpu_model: Test CPU: 8 cores\n{OLD}");
+        assert_eq!(
+            parse_metadata(&contents),
+            vec![("label", "laptop"), ("cpu_model", "Test CPU: 8 cores")]
+        );
+        assert_eq!(
+            parse_runs("old.csv", &contents).unwrap(),
+            parse_runs("old.csv", OLD).unwrap()
+        );
+        assert!(parse_metadata(OLD).is_empty());
+
+        // Line numbers in errors count the comment lines
+        let err =
+            parse_runs("bad.csv", "# label: x\nfield_type,run,proof_32_ms\nf64,0\n").unwrap_err();
+        assert!(err.starts_with("bad.csv line 3:"), "{err}");
+    }
+
+    #[test]
+    fn test_parse_alpha() {
+        assert_eq!(parse_alpha("0.01"), Ok(0.01));
+        assert!(parse_alpha("0").is_err());
+        assert!(parse_alpha("1").is_err());
+        assert!(parse_alpha("NaN").is_err());
+    }
+
+    #[test]
+    fn test_compare_flags_shifted_phases() {
+        let old = parse_runs("old.csv", OLD).unwrap();
+        let new = parse_runs(
+            "new.csv",
+            "field_type,run,proof_32_ms,verification_32_ms
+f64,0,1.0,0.9
+f64,1,1.1,1.0
+f64,2,1.2,0.9
+f64,3,1.3,1.1
+f256,0,3.0,1.5
+",
+        )
+        .unwrap();
+
+        let (comparisons, unmatched) = compare_runs(&old.runs, &new.runs, 0.05);
+        assert_eq!(comparisons.len(), 2);
+        let proof = &comparisons[0];
+        assert_eq!(proof.phase, "proof_32");
+        assert_eq!(proof.effect_size, 0.0);
+        assert!(!proof.significant);
+        let verification = &comparisons[1];
+        assert_eq!(verification.effect_size, 1.0);
+        assert!(verification.significant, "{verification:?}");
+        assert_eq!(verification.new_p99_ms, 1.1);
+        assert_eq!(
+            unmatched,
+            vec!["field_type=f128 (old only)", "field_type=f256 (new only)"]
+        );
+    }
+
+    #[test]
+    fn test_changed_artifacts() {
+        let old = parse_runs(
+            "old.csv",
+            "field_type,artifact_hash,run,positions,proof_32_ms
+f64,aa,0,1;5,1.0
+f64,aa,1,2;7,1.1
+f128,bb,0,1;5,2.0
+f256,,0,1;5,3.0
+",
+        )
+        .unwrap();
+        // Neither the hash nor the positions split the configuration
+        assert_eq!(old.runs["field_type=f64"]["proof_32"], vec![1.0, 1.1]);
+        assert_eq!(old.artifact_hashes.len(), 2);
+
+        let new = parse_runs(
+            "new.csv",
+            "field_type,artifact_hash,run,positions,proof_32_ms
+f64,aa,0,1;5,1.0
+f128,cc,0,1;5,2.0
+f256,,0,1;5,3.0
+",
+        )
+        .unwrap();
+        assert_eq!(
+            changed_artifacts(&old, &new),
+            vec![("field_type=f128", "bb", "cc")]
+        );
+        assert!(changed_artifacts(&old, &old).is_empty());
+    }
+}
@@ -5,7 +5,6 @@ use winter_math::FieldElement;
 use winter_rand_utils::rand_vector;
 
 use frida_poc::{
-    constants,
     core::data::{build_evaluations_from_data, encoded_data_element_count},
     prover::{
         batch_data_to_evaluations, builder::FridaProverBuilder, get_evaluations_from_positions,
@@ -15,8 +14,10 @@ use frida_poc::{
 
 use crate::common::{
     self, field_names, get_standard_data_sizes, get_standard_fri_options,
-    get_standard_validator_counts, Blake3F128, Blake3F64, F128Element, F64Element, RUNS,
+    get_standard_validator_counts, Blake3F128, Blake3F64, F128Element, F64Element, SweepTotals,
+    RUNS,
 };
+use crate::stats::{self, calibrate_timer_overhead};
 
 #[derive(Debug)]
 struct DefridaBenchmarkResult {
@@ -28,22 +29,34 @@ struct DefridaBenchmarkResult {
     data_size_kb: usize,
     num_validators: usize,
     num_queries: usize,
+    domain_size: usize,
+    log2_domain_size: u32,
+    extension_factor: f64,
     commitment_time_ms: f64,
     commitment_size_bytes: usize,
     avg_proof_time_ms: f64,
     avg_proof_size_bytes: usize,
+    dispersal: Dispersal,
     verification_setup_time_ms: f64,
-    avg_verification_time_ms: f64,
+    /// None when not every run verified a proof, as an average over fewer runs would not be
+    /// comparable; `invalid_reason` then says why.
+    avg_verification_time_ms: Option<f64>,
+    invalid_reason: Option<String>,
+    harness_overhead_ms: f64,
+    harness_overhead_pct: f64,
+    low_confidence_phases: Vec<&'static str>,
+    work: SweepTotals,
+    validators: Vec<ValidatorTotals>,
 }
 
 impl DefridaBenchmarkResult {
     fn csv_header() -> String {
-        "field_type,batch_size,blowup_factor,folding_factor,max_remainder_degree,data_size_kb,num_validators,num_queries,commitment_time_ms,commitment_size_bytes,avg_proof_time_ms,avg_proof_size_bytes,verification_setup_time_ms,avg_verification_time_ms".to_string()
+        "field_type,batch_size,blowup_factor,folding_factor,max_remainder_degree,data_size_kb,num_validators,num_queries,domain_size,log2_domain_size,extension_factor,commitment_time_ms,commitment_size_bytes,avg_proof_time_ms,avg_proof_size_bytes,dispersal_bytes,avg_validator_bytes,max_validator_bytes,dispersal_expansion,verification_setup_time_ms,avg_verification_time_ms,harness_overhead_ms,harness_overhead_pct,low_confidence_phases,invalid_reason".to_string()
     }
 
     fn to_csv(&self) -> String {
         format!(
-            "{},{},{},{},{},{},{},{},{:.3},{},{:.3},{},{:.3},{:.3}",
+            "{},{},{},{},{},{},{},{},{},{},{:.3},{:.3},{},{:.3},{},{},{},{},{:.2},{:.3},{},{:.3},{:.1},{},{}",
             self.field_type,
             self.batch_size,
             self.blowup_factor,
@@ -52,16 +65,204 @@ impl DefridaBenchmarkResult {
             self.data_size_kb,
             self.num_validators,
             self.num_queries,
+            self.domain_size,
+            self.log2_domain_size,
+            self.extension_factor,
             self.commitment_time_ms,
             self.commitment_size_bytes,
             self.avg_proof_time_ms,
             self.avg_proof_size_bytes,
+            self.dispersal.total_bytes,
+            self.dispersal.avg_validator_bytes,
+            self.dispersal.max_validator_bytes,
+            self.dispersal.expansion,
             self.verification_setup_time_ms,
             self.avg_verification_time_ms
+                .map_or(String::new(), |ms| format!("{ms:.3}")),
+            self.harness_overhead_ms,
+            self.harness_overhead_pct,
+            self.low_confidence_phases.join(";"),
+            self.invalid_reason.as_deref().unwrap_or_default()
         )
     }
+
+    /// Flags every timed phase whose average is too close to the timer overhead to be trusted.
+    fn mark_low_confidence(&mut self, timer_overhead: Duration) {
+        let mut phases = vec![
+            ("commitment", self.commitment_time_ms),
+            ("proof", self.avg_proof_time_ms),
+            ("verification_setup", self.verification_setup_time_ms),
+        ];
+        if let Some(ms) = self.avg_verification_time_ms {
+            phases.push(("verification", ms));
+        }
+        self.low_confidence_phases = stats::low_confidence_phases(&phases, timer_overhead);
+    }
+
+    /// Checks that the per-validator totals add up to the configuration-level proof columns, so
+    /// the breakdown can be presented next to them.
+    fn check_breakdown(&self) -> Result<(), String> {
+        let total = self
+            .validators
+            .iter()
+            .fold(ValidatorTotals::default(), |acc, v| acc + *v);
+        let proofs = self.work.proofs - self.work.verifications;
+        if total.proofs != proofs
+            || total.avg_proof_time_ms() != self.avg_proof_time_ms
+            || total.avg_proof_size_bytes() != self.avg_proof_size_bytes
+        {
+            return Err(format!(
+                "validator breakdown does not add up: {} proofs at {:.3} ms and {} bytes, expected {} at {:.3} ms and {} bytes",
+                total.proofs,
+                total.avg_proof_time_ms(),
+                total.avg_proof_size_bytes(),
+                proofs,
+                self.avg_proof_time_ms,
+                self.avg_proof_size_bytes
+            ));
+        }
+        Ok(())
+    }
+}
+
+/// What one validator held and spent across all runs of a configuration.
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+struct ValidatorTotals {
+    positions: usize,
+    proofs: usize,
+    proof_time: Duration,
+    proof_bytes: usize,
+    /// Only filled in when every validator's proof is verified.
+    evaluation_bytes: usize,
+    verifications: usize,
+    verification_time: Duration,
+}
+
+impl ValidatorTotals {
+    fn csv_header() -> String {
+        "validator,avg_positions,proofs,avg_proof_time_ms,avg_proof_size_bytes,avg_evaluation_bytes,avg_verification_time_ms".to_string()
+    }
+
+    /// `label` is the validator index, or `total` for the row summing all of them.
+    fn to_csv(&self, label: &str) -> String {
+        format!(
+            "{},{:.1},{},{:.3},{},{:.1},{:.3}",
+            label,
+            self.positions as f64 / RUNS as f64,
+            self.proofs,
+            self.avg_proof_time_ms(),
+            self.avg_proof_size_bytes(),
+            self.evaluation_bytes as f64 / RUNS as f64,
+            if self.verifications > 0 {
+                self.verification_time.as_secs_f64() * 1000.0 / self.verifications as f64
+            } else {
+                0.0
+            }
+        )
+    }
+
+    fn avg_proof_time_ms(&self) -> f64 {
+        if self.proofs > 0 {
+            self.proof_time.as_secs_f64() * 1000.0 / self.proofs as f64
+        } else {
+            0.0
+        }
+    }
+
+    fn avg_proof_size_bytes(&self) -> usize {
+        if self.proofs > 0 {
+            self.proof_bytes / self.proofs
+        } else {
+            0
+        }
+    }
+}
+
+impl std::ops::Add for ValidatorTotals {
+    type Output = Self;
+
+    fn add(self, other: Self) -> Self {
+        Self {
+            positions: self.positions + other.positions,
+            proofs: self.proofs + other.proofs,
+            proof_time: self.proof_time + other.proof_time,
+            proof_bytes: self.proof_bytes + other.proof_bytes,
+            evaluation_bytes: self.evaluation_bytes + other.evaluation_bytes,
+            verifications: self.verifications + other.verifications,
+            verification_time: self.verification_time + other.verification_time,
+        }
+    }
 }
 
+/// Bytes sent to the validators in one run, averaged over the runs, when each of them receives
+/// the commitment, its proof and the evaluations at its positions.
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+struct Dispersal {
+    /// Everything sent to all validators together.
+    total_bytes: usize,
+    avg_validator_bytes: usize,
+    /// What the validator with the most positions receives.
+    max_validator_bytes: usize,
+    /// Total bytes sent relative to the raw data.
+    expansion: f64,
+}
+
+impl Dispersal {
+    /// `position_bytes` is the size of the evaluations at one position, which holds one per
+    /// polynomial of the batch, and `data_bytes` the raw data committed to in one run.
+    fn new(
+        validators: &[ValidatorTotals],
+        commitment_size_bytes: usize,
+        position_bytes: usize,
+        data_bytes: usize,
+    ) -> Self {
+        // The commitment goes to every validator, including those that hold no positions
+        let received = validators
+            .iter()
+            .map(|v| commitment_size_bytes + (v.proof_bytes + v.positions * position_bytes) / RUNS)
+            .collect::<Vec<_>>();
+        let total_bytes = received.iter().sum::<usize>();
+        Dispersal {
+            total_bytes,
+            avg_validator_bytes: total_bytes / validators.len().max(1),
+            max_validator_bytes: received.iter().copied().max().unwrap_or(0),
+            expansion: total_bytes as f64 / data_bytes as f64,
+        }
+    }
+}
+
+/// Writes one row per validator, in assignment order, followed by their total.
+fn save_validator_breakdown(result: &DefridaBenchmarkResult, dir: &str) -> Result<String, String> {
+    result.check_breakdown()?;
+    let path = format!(
+        "{}/defrida_validators_{}_batch{}_{}KB_{}v_{}q.csv",
+        dir.trim_end_matches('/'),
+        result.field_type,
+        result.batch_size,
+        result.data_size_kb,
+        result.num_validators,
+        result.num_queries
+    );
+    let total = result
+        .validators
+        .iter()
+        .fold(ValidatorTotals::default(), |acc, v| acc + *v);
+    let mut rows = result
+        .validators
+        .iter()
+        .enumerate()
+        .map(|(i, v)| v.to_csv(&i.to_string()))
+        .collect::<Vec<_>>();
+    rows.push(total.to_csv("total"));
+    common::save_results_with_header(&rows, &path, &ValidatorTotals::csv_header(), |r| r.clone())
+        .map_err(|e| format!("failed to save {path}: {e}"))?;
+    Ok(path)
+}
+
+/// Splits `query_positions` among `n_validators` so that any `h` of them together hold every
+/// position. With at most one validator per position each gets a cyclic span of `s - h + 1`
+/// positions; with more, several validators share each starting position and every one gets the
+/// shortest span for which the guarantee holds.
 fn compute_position_assignments(
     n_validators: usize,
     query_positions: &[usize],
@@ -72,8 +273,12 @@ fn compute_position_assignments(
     if n == 0 {
         return vec![];
     }
+    if s == 0 {
+        return vec![Vec::new(); n];
+    }
     if n <= s {
-        let span_length = s.saturating_sub(h) + 1;
+        // h = 0 would give a span one longer than the query set, repeating a position
+        let span_length = (s.saturating_sub(h) + 1).min(s);
         (1..=n)
             .map(|i| {
                 let offset = (i - 1) % s;
@@ -83,31 +288,49 @@ fn compute_position_assignments(
             })
             .collect()
     } else {
-        let n_prime = (n / s) * s;
-        if n_prime == 0 {
-            return vec![Vec::new(); n];
-        }
-        let replication_factor = n_prime / s;
-        let h_prime = h.saturating_sub(n - n_prime).div_ceil(replication_factor);
-        let base_subsets = compute_position_assignments(s, query_positions, h_prime);
-        (1..=n)
+        // Validator i starts at position i mod s, so each start is shared by n / s or n / s + 1
+        // validators. A position is missed only by validators whose spans do not reach it, and
+        // any h validators hold it as long as fewer than h miss it.
+        let sharing = (0..s)
+            .map(|start| n / s + usize::from(start < n % s))
+            .collect::<Vec<_>>();
+        let span_length = (1..=s)
+            .find(|&length| {
+                (0..s).all(|p| {
+                    let holders = (0..length).map(|j| sharing[(p + s - j) % s]).sum::<usize>();
+                    n - holders < h
+                })
+            })
+            .unwrap_or(s);
+        (0..n)
             .map(|i| {
-                if i <= n_prime {
-                    base_subsets[(i - 1) % s].clone()
-                } else {
-                    Vec::new()
-                }
+                (0..span_length)
+                    .map(|j| query_positions[(i % s + j) % s])
+                    .collect()
             })
             .collect()
     }
 }
 
+/// Checks that every run verified a proof before its time is reported. A run can only skip
+/// verification if no validator was assigned any positions, and an empty set of positions must
+/// never be timed as a verification.
+fn check_verifications(verifications: usize, runs: usize) -> Result<(), String> {
+    if verifications < runs {
+        return Err(format!(
+            "only {verifications} of {runs} runs verified a proof, as no validator held any positions in the others"
+        ));
+    }
+    Ok(())
+}
+
 fn benchmark_non_batched<E, H>(
     options: FriOptions,
     data_size: usize,
     num_validators: usize,
     num_queries: usize,
     field_name: &str,
+    verify_all: bool,
 ) -> DefridaBenchmarkResult
 where
     E: FieldElement,
@@ -120,7 +343,12 @@ where
     let mut total_verification_setup_time = Duration::ZERO;
     let mut total_verification_time = Duration::ZERO;
     let mut total_proofs_generated = 0;
+    let mut total_verifications = 0;
+    let mut domain_size = 0;
+    let mut validators = vec![ValidatorTotals::default(); num_validators];
+    let mut breakdown_verification_time = Duration::ZERO;
 
+    let config_start = Instant::now();
     for _ in 0..RUNS {
         let data = rand_vector::<u8>(data_size);
         let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
@@ -131,6 +359,7 @@ where
             .expect("Commitment generation failed");
         total_commitment_time += start.elapsed();
 
+        domain_size = prover_commitment.domain_size;
         let commitment_size = prover_commitment.roots.len() * 32 + 16;
         total_commitment_size += commitment_size;
 
@@ -138,13 +367,22 @@ where
         let h = f + 1;
         let validator_positions = compute_position_assignments(num_validators, &base_positions, h);
 
-        for positions in &validator_positions {
+        let mut opened = Vec::new();
+        for (i, positions) in validator_positions.iter().enumerate() {
+            validators[i].positions += positions.len();
             if !positions.is_empty() {
                 let start = Instant::now();
                 let proof = prover.open(positions);
-                total_proof_times += start.elapsed();
+                let elapsed = start.elapsed();
+                total_proof_times += elapsed;
                 total_proof_sizes += proof.size();
                 total_proofs_generated += 1;
+                validators[i].proof_time += elapsed;
+                validators[i].proof_bytes += proof.size();
+                validators[i].proofs += 1;
+                if verify_all {
+                    opened.push((i, proof));
+                }
             }
         }
 
@@ -168,9 +406,47 @@ where
             let verify_start = Instant::now();
             verifier.verify(&proof, &evaluations, positions).unwrap();
             total_verification_time += verify_start.elapsed();
+            total_verifications += 1;
+        }
+
+        for (i, proof) in &opened {
+            let positions = &validator_positions[*i];
+            let evaluations: Vec<E> = positions.iter().map(|&p| all_evaluations[p]).collect();
+
+            let verify_start = Instant::now();
+            verifier.verify(proof, &evaluations, positions).unwrap();
+            let elapsed = verify_start.elapsed();
+            breakdown_verification_time += elapsed;
+            validators[*i].verification_time += elapsed;
+            validators[*i].verifications += 1;
+            validators[*i].evaluation_bytes += evaluations.len() * E::ELEMENT_BYTES;
         }
     }
 
+    let verified = check_verifications(total_verifications, RUNS);
+    let (log2_domain_size, extension_factor) =
+        common::domain_geometry(domain_size, encoded_data_element_count::<E>(data_size));
+    let dispersal = Dispersal::new(
+        &validators,
+        total_commitment_size / RUNS,
+        E::ELEMENT_BYTES,
+        data_size,
+    );
+    let timed = total_commitment_time
+        + total_proof_times
+        + total_verification_setup_time
+        + total_verification_time
+        + breakdown_verification_time;
+    let (harness_overhead_ms, harness_overhead_pct) =
+        common::harness_overhead(config_start.elapsed(), timed, RUNS);
+    common::warn_on_overhead(
+        &format!(
+            "{field_name} batch=1 data={}KB validators={num_validators} queries={num_queries}",
+            data_size / 1024
+        ),
+        harness_overhead_pct,
+    );
+
     DefridaBenchmarkResult {
         field_type: field_name.to_string(),
         batch_size: 1,
@@ -180,6 +456,9 @@ where
         data_size_kb: data_size / 1024,
         num_validators,
         num_queries,
+        domain_size,
+        log2_domain_size,
+        extension_factor,
         commitment_time_ms: total_commitment_time.as_secs_f64() * 1000.0 / RUNS as f64,
         commitment_size_bytes: total_commitment_size / RUNS,
         avg_proof_time_ms: if total_proofs_generated > 0 {
@@ -192,9 +471,23 @@ where
         } else {
             0
         },
+        dispersal,
         verification_setup_time_ms: total_verification_setup_time.as_secs_f64() * 1000.0
             / RUNS as f64,
-        avg_verification_time_ms: total_verification_time.as_secs_f64() * 1000.0 / RUNS as f64,
+        avg_verification_time_ms: verified
+            .is_ok()
+            .then(|| total_verification_time.as_secs_f64() * 1000.0 / RUNS as f64),
+        invalid_reason: verified.err(),
+        harness_overhead_ms,
+        harness_overhead_pct,
+        low_confidence_phases: Vec::new(),
+        work: SweepTotals {
+            data_bytes: RUNS * data_size,
+            // Each verification opens its own proof on top of the per-validator ones
+            proofs: total_proofs_generated + total_verifications,
+            verifications: total_verifications,
+        },
+        validators,
     }
 }
 
@@ -205,6 +498,7 @@ fn benchmark_batched<E, H>(
     num_validators: usize,
     num_queries: usize,
     field_name: &str,
+    verify_all: bool,
 ) -> DefridaBenchmarkResult
 where
     E: FieldElement,
@@ -217,7 +511,12 @@ where
     let mut total_verification_setup_time = Duration::ZERO;
     let mut total_verification_time = Duration::ZERO;
     let mut total_proofs_generated = 0;
+    let mut total_verifications = 0;
+    let mut domain_size = 0;
+    let mut validators = vec![ValidatorTotals::default(); num_validators];
+    let mut breakdown_verification_time = Duration::ZERO;
 
+    let config_start = Instant::now();
     for _ in 0..RUNS {
         let mut data_list = vec![];
         for _ in 0..batch_size {
@@ -232,6 +531,7 @@ where
             .expect("Batch commitment generation failed");
         total_commitment_time += start.elapsed();
 
+        domain_size = prover_commitment.domain_size;
         let commitment_size = prover_commitment.roots.len() * 32 + 16;
         total_commitment_size += commitment_size;
 
@@ -239,34 +539,30 @@ where
         let h = f + 1;
         let validator_positions = compute_position_assignments(num_validators, &base_positions, h);
 
-        for positions in &validator_positions {
+        let mut opened = Vec::new();
+        for (i, positions) in validator_positions.iter().enumerate() {
+            validators[i].positions += positions.len();
             if !positions.is_empty() {
                 let start = Instant::now();
                 let proof = prover.open(positions);
-                total_proof_times += start.elapsed();
+                let elapsed = start.elapsed();
+                total_proof_times += elapsed;
                 total_proof_sizes += proof.size();
                 total_proofs_generated += 1;
+                validators[i].proof_time += elapsed;
+                validators[i].proof_bytes += proof.size();
+                validators[i].proofs += 1;
+                if verify_all {
+                    opened.push((i, proof));
+                }
             }
         }
 
-        let blowup_factor = options.blowup_factor();
-        let max_data_len = encoded_data_element_count::<E>(
-            data_list
-                .iter()
-                .map(|data| data.len())
-                .max()
-                .unwrap_or_default(),
-        );
-        let domain_size = usize::max(
-            (max_data_len * blowup_factor).next_power_of_two(),
-            constants::MIN_DOMAIN_SIZE,
-        );
-
         let all_evaluations = batch_data_to_evaluations::<E>(
             &data_list,
             batch_size,
             domain_size,
-            blowup_factor,
+            options.blowup_factor(),
             options.folding_factor(),
         )
         .unwrap();
@@ -290,9 +586,53 @@ where
             let verify_start = Instant::now();
             verifier.verify(&proof, &evaluations, positions).unwrap();
             total_verification_time += verify_start.elapsed();
+            total_verifications += 1;
+        }
+
+        for (i, proof) in &opened {
+            let positions = &validator_positions[*i];
+            let evaluations = get_evaluations_from_positions(
+                &all_evaluations,
+                positions,
+                batch_size,
+                domain_size,
+                options.folding_factor(),
+            );
+
+            let verify_start = Instant::now();
+            verifier.verify(proof, &evaluations, positions).unwrap();
+            let elapsed = verify_start.elapsed();
+            breakdown_verification_time += elapsed;
+            validators[*i].verification_time += elapsed;
+            validators[*i].verifications += 1;
+            validators[*i].evaluation_bytes += evaluations.len() * E::ELEMENT_BYTES;
         }
     }
 
+    let verified = check_verifications(total_verifications, RUNS);
+    let (log2_domain_size, extension_factor) =
+        common::domain_geometry(domain_size, encoded_data_element_count::<E>(data_size));
+    let dispersal = Dispersal::new(
+        &validators,
+        total_commitment_size / RUNS,
+        batch_size * E::ELEMENT_BYTES,
+        batch_size * data_size,
+    );
+    let timed = total_commitment_time
+        + total_proof_times
+        + total_verification_setup_time
+        + total_verification_time
+        + breakdown_verification_time;
+    let (harness_overhead_ms, harness_overhead_pct) =
+        common::harness_overhead(config_start.elapsed(), timed, RUNS);
+    common::warn_on_overhead(
+        &format!(
+            "{field_name} batch={batch_size} data={}KB validators={num_validators} queries={num_queries}",
+            data_size / 1024
+        ),
+        harness_overhead_pct,
+    );
+
     DefridaBenchmarkResult {
         field_type: field_name.to_string(),
         batch_size,
@@ -302,6 +642,9 @@ where
         data_size_kb: data_size / 1024,
         num_validators,
         num_queries,
+        domain_size,
+        log2_domain_size,
+        extension_factor,
         commitment_time_ms: total_commitment_time.as_secs_f64() * 1000.0 / RUNS as f64,
         commitment_size_bytes: total_commitment_size / RUNS,
         avg_proof_time_ms: if total_proofs_generated > 0 {
@@ -314,9 +657,22 @@ where
         } else {
             0
         },
+        dispersal,
         verification_setup_time_ms: total_verification_setup_time.as_secs_f64() * 1000.0
             / RUNS as f64,
-        avg_verification_time_ms: total_verification_time.as_secs_f64() * 1000.0 / RUNS as f64,
+        avg_verification_time_ms: verified
+            .is_ok()
+            .then(|| total_verification_time.as_secs_f64() * 1000.0 / RUNS as f64),
+        invalid_reason: verified.err(),
+        harness_overhead_ms,
+        harness_overhead_pct,
+        low_confidence_phases: Vec::new(),
+        work: SweepTotals {
+            data_bytes: RUNS * batch_size * data_size,
+            proofs: total_proofs_generated + total_verifications,
+            verifications: total_verifications,
+        },
+        validators,
     }
 }
 
@@ -331,6 +687,8 @@ pub fn run_full_benchmark(output_path: &str) {
     let mut results = Vec::new();
 
     println!("Running full deFRIDA benchmark suite...");
+    let sweep_start = Instant::now();
+    let timer_overhead = calibrate_timer_overhead();
     println!(
         "Total configurations: {}",
         fri_options.len()
@@ -356,6 +714,7 @@ pub fn run_full_benchmark(output_path: &str) {
                                     num_validators,
                                     num_queries,
                                     field_names::F64,
+                                    false,
                                 )
                             }) {
                                 results.push(result);
@@ -368,6 +727,7 @@ pub fn run_full_benchmark(output_path: &str) {
                                     num_validators,
                                     num_queries,
                                     field_names::F128,
+                                    false,
                                 )
                             }) {
                                 results.push(result);
@@ -381,6 +741,7 @@ pub fn run_full_benchmark(output_path: &str) {
                                     num_validators,
                                     num_queries,
                                     field_names::F64,
+                                    false,
                                 )
                             }) {
                                 results.push(result);
@@ -394,6 +755,7 @@ pub fn run_full_benchmark(output_path: &str) {
                                     num_validators,
                                     num_queries,
                                     field_names::F128,
+                                    false,
                                 )
                             }) {
                                 results.push(result);
@@ -405,6 +767,17 @@ pub fn run_full_benchmark(output_path: &str) {
         }
     }
 
+    for result in &mut results {
+        result.mark_low_confidence(timer_overhead);
+    }
+    common::print_low_confidence_summary(
+        results
+            .iter()
+            .filter(|r| !r.low_confidence_phases.is_empty())
+            .count(),
+        timer_overhead,
+    );
+
     common::save_results_with_header(
         &results,
         output_path,
@@ -412,10 +785,20 @@ pub fn run_full_benchmark(output_path: &str) {
         |r| r.to_csv(),
     )
     .expect("Failed to save results");
+    common::print_overhead_summary(
+        &results
+            .iter()
+            .map(|r| r.harness_overhead_pct)
+            .collect::<Vec<_>>(),
+    );
     println!(
         "deFRIDA benchmark completed with {} successful results",
         results.len()
     );
+    let totals = results
+        .iter()
+        .fold(SweepTotals::default(), |acc, r| acc + r.work);
+    println!("{}", totals.summary(sweep_start.elapsed()));
 }
 
 pub struct CustomDefridaBenchmarkConfig<'a> {
@@ -427,6 +810,9 @@ pub struct CustomDefridaBenchmarkConfig<'a> {
     pub num_queries: usize,
     pub batch_size: usize,
     pub output_path: &'a str,
+    /// Directory for a per-validator CSV of each field type; also verifies every validator's
+    /// proof rather than one per run.
+    pub breakdown_dir: Option<&'a str>,
 }
 
 pub fn run_custom_benchmark(config: CustomDefridaBenchmarkConfig) {
@@ -438,6 +824,7 @@ pub fn run_custom_benchmark(config: CustomDefridaBenchmarkConfig) {
     let mut results = Vec::new();
 
     println!("Running custom deFRIDA benchmark...");
+    let timer_overhead = calibrate_timer_overhead();
     println!(
         "Parameters: blowup={}, folding={}, remainder={}, data={}KB, validators={}, queries={}, batch_size={}",
         config.blowup_factor,
@@ -457,6 +844,7 @@ pub fn run_custom_benchmark(config: CustomDefridaBenchmarkConfig) {
             config.num_validators,
             config.num_queries,
             field_names::F64,
+            config.breakdown_dir.is_some(),
         );
         results.push(result_f64);
 
@@ -467,6 +855,7 @@ pub fn run_custom_benchmark(config: CustomDefridaBenchmarkConfig) {
             config.num_validators,
             config.num_queries,
             field_names::F128,
+            config.breakdown_dir.is_some(),
         );
         results.push(result_f128);
     } else {
@@ -476,6 +865,7 @@ pub fn run_custom_benchmark(config: CustomDefridaBenchmarkConfig) {
             config.num_validators,
             config.num_queries,
             field_names::F64,
+            config.breakdown_dir.is_some(),
         );
         results.push(result_f64);
 
@@ -485,10 +875,22 @@ pub fn run_custom_benchmark(config: CustomDefridaBenchmarkConfig) {
             config.num_validators,
             config.num_queries,
             field_names::F128,
+            config.breakdown_dir.is_some(),
         );
         results.push(result_f128);
     }
 
+    for result in &mut results {
+        result.mark_low_confidence(timer_overhead);
+    }
+    common::print_low_confidence_summary(
+        results
+            .iter()
+            .filter(|r| !r.low_confidence_phases.is_empty())
+            .count(),
+        timer_overhead,
+    );
+
     common::save_results_with_header(
         &results,
         config.output_path,
@@ -496,5 +898,183 @@ pub fn run_custom_benchmark(config: CustomDefridaBenchmarkConfig) {
         |r| r.to_csv(),
     )
     .expect("Failed to save results");
+    if let Some(dir) = config.breakdown_dir {
+        for result in &results {
+            match save_validator_breakdown(result, dir) {
+                Ok(path) => println!("Validator breakdown saved to {path}"),
+                Err(e) => eprintln!("Error: {e}"),
+            }
+        }
+    }
     println!("Custom deFRIDA benchmark completed successfully");
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// Returns true if every choice of `h` validators together holds all of `positions`.
+    fn any_h_cover(assignments: &[Vec<usize>], positions: &[usize], h: usize) -> bool {
+        fn covers(
+            assignments: &[Vec<usize>],
+            positions: &[usize],
+            start: usize,
+            left: usize,
+            held: &mut Vec<usize>,
+        ) -> bool {
+            if left == 0 {
+                return positions.iter().all(|p| held.contains(p));
+            }
+            (start..=assignments.len() - left).all(|i| {
+                let len = held.len();
+                held.extend(&assignments[i]);
+                let ok = covers(assignments, positions, i + 1, left - 1, held);
+                held.truncate(len);
+                ok
+            })
+        }
+        covers(assignments, positions, 0, h, &mut Vec::new())
+    }
+
+    #[test]
+    fn test_assignments_cover_with_any_honest_quorum() {
+        let positions = [3, 14, 15, 92, 65, 35];
+        // Fewer, as many and more validators than positions, including counts that are not a
+        // multiple of the number of positions, with fewer or more than h validators over
+        for n in [1, 2, 4, 6, 7, 8, 11, 12, 13, 17] {
+            let h = (n - 1) / 3 + 1;
+            let assignments = compute_position_assignments(n, &positions, h);
+            assert_eq!(assignments.len(), n);
+            assert!(
+                assignments.iter().flatten().all(|p| positions.contains(p)),
+                "n={n}"
+            );
+            assert!(any_h_cover(&assignments, &positions, h), "n={n} h={h}");
+        }
+    }
+
+    #[test]
+    fn test_assignments_leave_no_validator_empty() {
+        let positions = [3, 14, 15, 92, 65, 35];
+        // Five validators over a multiple of the positions, more than h = 4
+        let assignments = compute_position_assignments(11, &positions, 4);
+        assert!(assignments.iter().all(|a| a.len() == 5), "{assignments:?}");
+        // A multiple of the positions gives the same spans as before: s - ceil(h / 2) + 1
+        let assignments = compute_position_assignments(12, &positions, 4);
+        assert!(assignments.iter().all(|a| a.len() == 5), "{assignments:?}");
+    }
+
+    #[test]
+    fn test_assignment_span_lengths() {
+        let positions = [0, 1, 2, 3, 4, 5, 6, 7];
+        let assignments = compute_position_assignments(8, &positions, 3);
+        assert!(assignments.iter().all(|a| a.len() == 6));
+        assert_eq!(assignments[7], vec![7, 0, 1, 2, 3, 4]);
+
+        // h larger than the query set still gives every validator one position
+        let assignments = compute_position_assignments(4, &positions, 20);
+        assert!(assignments.iter().all(|a| a.len() == 1));
+
+        // h = 0 gives every validator the whole set, without repeating a position
+        let assignments = compute_position_assignments(4, &positions, 0);
+        assert!(assignments.iter().all(|a| a.len() == positions.len()));
+    }
+
+    #[test]
+    fn test_validator_breakdown_adds_up() {
+        let _prover = common::lock_prover();
+        let mut result = benchmark_batched::<F64Element, Blake3F64>(
+            FriOptions::new(2, 2, 0),
+            1024,
+            2,
+            4,
+            8,
+            field_names::F64,
+            true,
+        );
+        assert_eq!(result.validators.len(), 4);
+        assert!(result
+            .validators
+            .iter()
+            .all(|v| v.proofs == RUNS && v.verifications == RUNS && v.evaluation_bytes > 0));
+        assert_eq!(result.check_breakdown(), Ok(()));
+        // The evaluations actually sent hold one element per polynomial at every position
+        assert!(result
+            .validators
+            .iter()
+            .all(|v| v.evaluation_bytes == v.positions * 2 * F64Element::ELEMENT_BYTES));
+        assert_eq!(
+            result.dispersal,
+            Dispersal::new(
+                &result.validators,
+                result.commitment_size_bytes,
+                2 * F64Element::ELEMENT_BYTES,
+                2 * 1024
+            )
+        );
+
+        result.validators[3].proof_bytes += 1024 * RUNS;
+        assert!(result.check_breakdown().is_err());
+    }
+
+    #[test]
+    fn test_dispersal() {
+        let validator = |positions, proof_bytes| ValidatorTotals {
+            positions: positions * RUNS,
+            proof_bytes: proof_bytes * RUNS,
+            ..Default::default()
+        };
+        // Three validators with 4, 4 and 2 positions of 16 bytes, and one left without any
+        let validators = [
+            validator(4, 1000),
+            validator(4, 1000),
+            validator(2, 600),
+            validator(0, 0),
+        ];
+        let dispersal = Dispersal::new(&validators, 100, 16, 1024);
+        assert_eq!(dispersal.max_validator_bytes, 100 + 1000 + 64);
+        assert_eq!(
+            dispersal.total_bytes,
+            4 * 100 + 2 * (1000 + 64) + (600 + 32)
+        );
+        assert_eq!(dispersal.avg_validator_bytes, dispersal.total_bytes / 4);
+        assert_eq!(dispersal.expansion, dispersal.total_bytes as f64 / 1024.0);
+        assert_eq!(Dispersal::new(&[], 100, 16, 1024).total_bytes, 0);
+    }
+
+    #[test]
+    fn test_unverified_runs_are_not_timed() {
+        let _prover = common::lock_prover();
+        assert_eq!(check_verifications(RUNS, RUNS), Ok(()));
+        let err = check_verifications(0, RUNS).unwrap_err();
+        assert!(err.starts_with("only 0 of 10 runs verified"), "{err}");
+
+        let result = benchmark_non_batched::<F64Element, Blake3F64>(
+            FriOptions::new(2, 2, 0),
+            1024,
+            3,
+            8,
+            field_names::F64,
+            false,
+        );
+        assert!(result.avg_verification_time_ms.is_some());
+        assert_eq!(result.invalid_reason, None);
+        assert_eq!(
+            result.to_csv().split(',').count(),
+            DefridaBenchmarkResult::csv_header().split(',').count()
+        );
+    }
+
+    #[test]
+    fn test_assignment_degenerate_inputs() {
+        assert!(compute_position_assignments(0, &[1, 2, 3], 1).is_empty());
+        assert_eq!(
+            compute_position_assignments(1, &[1, 2, 3], 1),
+            vec![vec![1, 2, 3]]
+        );
+        assert_eq!(
+            compute_position_assignments(3, &[], 1),
+            vec![Vec::<usize>::new(); 3]
+        );
+    }
+}
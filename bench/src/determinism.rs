@@ -0,0 +1,209 @@
+use winter_crypto::ElementHasher;
+use winter_fri::FriOptions;
+use winter_math::FieldElement;
+use winter_utils::Serializable;
+
+use frida_poc::prover::builder::FridaProverBuilder;
+
+use crate::common::{
+    field_names, Blake3F128, Blake3F64, F128Element, F64Element, HashFunction, InputSource,
+    Sha3F128, Sha3F64,
+};
+
+/// Configuration of the determinism check, taken from the custom benchmark it precedes.
+pub struct DeterminismConfig {
+    pub blowup_factor: usize,
+    pub folding_factor: usize,
+    pub max_remainder_degree: usize,
+    pub data_size: usize,
+    pub batch_size: usize,
+    pub num_queries: usize,
+    pub hash: HashFunction,
+    pub input: InputSource,
+}
+
+/// Serialized outputs of a single pass through the commit and open pipeline.
+struct PipelineArtifacts {
+    commitment: Vec<u8>,
+    evaluations: Vec<u8>,
+    proof: Vec<u8>,
+}
+
+impl PipelineArtifacts {
+    /// Returns the name of the first artifact that differs from `other`, along with the byte
+    /// offset of the first difference.
+    fn first_divergence(&self, other: &Self) -> Option<(&'static str, usize)> {
+        [
+            ("commitment", &self.commitment, &other.commitment),
+            (
+                "first layer evaluations",
+                &self.evaluations,
+                &other.evaluations,
+            ),
+            ("opening proof", &self.proof, &other.proof),
+        ]
+        .into_iter()
+        .find_map(|(name, a, b)| first_mismatch(a, b).map(|offset| (name, offset)))
+    }
+}
+
+fn first_mismatch(a: &[u8], b: &[u8]) -> Option<usize> {
+    match a.iter().zip(b).position(|(x, y)| x != y) {
+        Some(offset) => Some(offset),
+        None if a.len() != b.len() => Some(usize::min(a.len(), b.len())),
+        None => None,
+    }
+}
+
+fn run_pipeline<E, H>(
+    options: &FriOptions,
+    data_list: &[Vec<u8>],
+    num_queries: usize,
+) -> Result<PipelineArtifacts, String>
+where
+    E: FieldElement,
+    H: ElementHasher<BaseField = E::BaseField>,
+{
+    let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
+    let (com, prover) = if data_list.len() > 1 {
+        prover_builder.commit_and_prove_batch(data_list, num_queries)
+    } else {
+        prover_builder.commit_and_prove(&data_list[0], num_queries)
+    }
+    .map_err(|e| format!("Commitment generation failed: {e}"))?;
+
+    let domain_size = com.domain_size;
+    let proof = prover.open(&[0, 1, domain_size / 2, domain_size - 1]);
+
+    Ok(PipelineArtifacts {
+        commitment: com.to_bytes(),
+        evaluations: E::elements_as_bytes(prover.get_first_layer_evaluations()).to_vec(),
+        proof: proof.to_bytes(),
+    })
+}
+
+fn check<E, H>(
+    options: &FriOptions,
+    config: &DeterminismConfig,
+    field_name: &str,
+) -> Result<(), String>
+where
+    E: FieldElement,
+    H: ElementHasher<BaseField = E::BaseField>,
+{
+    // Drawn once, so that both passes see the same bytes even for random input.
+    let data_list = (0..config.batch_size)
+        .map(|index| config.input.blob(index, config.data_size))
+        .collect::<Vec<_>>();
+
+    let first = run_pipeline::<E, H>(options, &data_list, config.num_queries)
+        .map_err(|e| format!("{field_name}: {e}"))?;
+    let second = run_pipeline::<E, H>(options, &data_list, config.num_queries)
+        .map_err(|e| format!("{field_name}: {e}"))?;
+
+    match first.first_divergence(&second) {
+        Some((artifact, offset)) => Err(format!(
+            "{field_name}: {artifact} differs between passes at byte {offset}"
+        )),
+        None => {
+            println!(
+                "  {field_name}: commitment ({} bytes), evaluations and opening proof are identical",
+                first.commitment.len()
+            );
+            Ok(())
+        }
+    }
+}
+
+fn check_fields<H64, H128>(options: &FriOptions, config: &DeterminismConfig) -> Vec<String>
+where
+    H64: ElementHasher<BaseField = F64Element>,
+    H128: ElementHasher<BaseField = F128Element>,
+{
+    [
+        check::<F64Element, H64>(options, config, field_names::F64),
+        check::<F128Element, H128>(options, config, field_names::F128),
+    ]
+    .into_iter()
+    .filter_map(Result::err)
+    .collect()
+}
+
+/// Runs the configuration twice on identical input for both field types and checks that every
+/// produced artifact is byte-identical between the two passes.
+pub fn run_determinism_check(config: DeterminismConfig) -> Result<(), String> {
+    let options = FriOptions::new(
+        config.blowup_factor,
+        config.folding_factor,
+        config.max_remainder_degree,
+    );
+
+    println!("Running determinism check ({})...", config.hash.name());
+    let errors = match config.hash {
+        HashFunction::Blake3 => check_fields::<Blake3F64, Blake3F128>(&options, &config),
+        HashFunction::Sha3 => check_fields::<Sha3F64, Sha3F128>(&options, &config),
+    };
+    if errors.is_empty() {
+        Ok(())
+    } else {
+        Err(errors.join("; "))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::common;
+
+    fn artifacts(commitment: &[u8], evaluations: &[u8], proof: &[u8]) -> PipelineArtifacts {
+        PipelineArtifacts {
+            commitment: commitment.to_vec(),
+            evaluations: evaluations.to_vec(),
+            proof: proof.to_vec(),
+        }
+    }
+
+    #[test]
+    fn test_first_mismatch() {
+        assert_eq!(first_mismatch(&[1, 2, 3], &[1, 2, 3]), None);
+        assert_eq!(first_mismatch(&[], &[]), None);
+        assert_eq!(first_mismatch(&[1, 2, 3], &[1, 9, 3]), Some(1));
+        assert_eq!(first_mismatch(&[1, 2, 3], &[1, 2]), Some(2));
+        assert_eq!(first_mismatch(&[], &[7]), Some(0));
+    }
+
+    #[test]
+    fn test_first_divergence() {
+        let base = artifacts(&[1, 2], &[3, 4], &[5, 6]);
+        assert_eq!(
+            base.first_divergence(&artifacts(&[1, 2], &[3, 4], &[5, 6])),
+            None
+        );
+        assert_eq!(
+            base.first_divergence(&artifacts(&[1, 2], &[3, 0], &[5, 0])),
+            Some(("first layer evaluations", 1))
+        );
+        assert_eq!(
+            base.first_divergence(&artifacts(&[1, 2], &[3, 4], &[5, 6, 7])),
+            Some(("opening proof", 2))
+        );
+    }
+
+    #[test]
+    fn test_check_passes_for_each_hash() {
+        let _prover = common::lock_prover();
+        for hash in HashFunction::ALL {
+            let config = DeterminismConfig {
+                blowup_factor: 2,
+                folding_factor: 2,
+                max_remainder_degree: 0,
+                data_size: 1024,
+                batch_size: 2,
+                num_queries: 8,
+                hash,
+                input: InputSource::Seeded(1),
+            };
+            assert_eq!(run_determinism_check(config), Ok(()));
+        }
+    }
+}
@@ -0,0 +1,291 @@
+use std::time::{Duration, Instant};
+use winter_crypto::ElementHasher;
+use winter_fri::FriOptions;
+use winter_math::FieldElement;
+use winter_rand_utils::{rand_value, rand_vector};
+
+use frida_poc::{
+    prover::{builder::FridaProverBuilder, get_evaluations_from_positions},
+    verifier::das::FridaDasVerifier,
+};
+
+use crate::common::{self, field_names, Blake3F128, Blake3F64, F128Element, F64Element, RUNS};
+
+/// Number of queries used for the commitment; the openings under test are independent of it.
+const COMMITMENT_QUERIES: usize = 32;
+
+/// Compares verifying K single-position openings one call at a time against verifying one opening
+/// of all K positions in a single call.
+#[derive(Debug)]
+struct FragmentationResult {
+    field_type: String,
+    batch_size: usize,
+    blowup_factor: usize,
+    folding_factor: usize,
+    max_remainder_degree: usize,
+    data_size_kb: usize,
+    domain_size: usize,
+    num_positions: usize,
+    fragmented_verification_ms: f64,
+    combined_verification_ms: f64,
+    fixed_cost_per_call_ms: f64,
+    fragmentation_penalty: f64,
+    fragmented_proof_size_bytes: usize,
+    combined_proof_size_bytes: usize,
+}
+
+impl FragmentationResult {
+    fn csv_header() -> String {
+        "field_type,batch_size,blowup_factor,folding_factor,max_remainder_degree,data_size_kb,domain_size,num_positions,fragmented_verification_ms,combined_verification_ms,fixed_cost_per_call_ms,fragmentation_penalty,fragmented_proof_size_bytes,combined_proof_size_bytes".to_string()
+    }
+
+    fn to_csv(&self) -> String {
+        format!(
+            "{},{},{},{},{},{},{},{},{:.3},{:.3},{:.3},{:.2},{},{}",
+            self.field_type,
+            self.batch_size,
+            self.blowup_factor,
+            self.folding_factor,
+            self.max_remainder_degree,
+            self.data_size_kb,
+            self.domain_size,
+            self.num_positions,
+            self.fragmented_verification_ms,
+            self.combined_verification_ms,
+            self.fixed_cost_per_call_ms,
+            self.fragmentation_penalty,
+            self.fragmented_proof_size_bytes,
+            self.combined_proof_size_bytes
+        )
+    }
+}
+
+/// Returns `count` distinct positions spread evenly over the domain from a random start.
+fn spread_positions(count: usize, domain_size: usize) -> Vec<usize> {
+    let start = rand_value::<u64>() as usize % domain_size;
+    let stride = domain_size / count;
+    (0..count)
+        .map(|i| (start + i * stride) % domain_size)
+        .collect()
+}
+
+fn benchmark<E, H>(
+    options: &FriOptions,
+    data_size: usize,
+    batch_size: usize,
+    num_positions: usize,
+    field_name: &str,
+) -> Result<FragmentationResult, String>
+where
+    E: FieldElement,
+    H: ElementHasher<BaseField = E::BaseField>,
+{
+    if num_positions == 0 {
+        return Err("at least one position is needed".to_string());
+    }
+
+    let mut fragmented_time = Duration::ZERO;
+    let mut combined_time = Duration::ZERO;
+    let mut fragmented_proof_size = 0;
+    let mut combined_proof_size = 0;
+    let mut domain_size = 0;
+
+    for _ in 0..RUNS {
+        let data_list = (0..batch_size)
+            .map(|_| rand_vector::<u8>(data_size))
+            .collect::<Vec<_>>();
+        let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
+        let (com, prover) = if batch_size > 1 {
+            prover_builder.commit_and_prove_batch(&data_list, COMMITMENT_QUERIES)
+        } else {
+            prover_builder.commit_and_prove(&data_list[0], COMMITMENT_QUERIES)
+        }
+        .map_err(|e| format!("commitment failed: {e}"))?;
+        domain_size = com.domain_size;
+        if num_positions > domain_size {
+            return Err(format!(
+                "{num_positions} positions do not fit in a domain of size {domain_size}"
+            ));
+        }
+
+        let positions = spread_positions(num_positions, domain_size);
+        let evaluations = get_evaluations_from_positions(
+            prover.get_first_layer_evaluations(),
+            &positions,
+            batch_size,
+            domain_size,
+            options.folding_factor(),
+        );
+        let single_proofs = positions
+            .iter()
+            .map(|&p| prover.open(&[p]))
+            .collect::<Vec<_>>();
+        let combined_proof = prover.open(&positions);
+        fragmented_proof_size += single_proofs.iter().map(|p| p.size()).sum::<usize>();
+        combined_proof_size += combined_proof.size();
+
+        let verifier = FridaDasVerifier::<E, H, H>::new(com, options.clone())
+            .map_err(|e| format!("verifier setup failed: {e}"))?
+            .0;
+
+        let timer = Instant::now();
+        for (i, proof) in single_proofs.iter().enumerate() {
+            verifier
+                .verify(
+                    proof,
+                    &evaluations[i * batch_size..(i + 1) * batch_size],
+                    &positions[i..i + 1],
+                )
+                .map_err(|e| format!("position {} failed to verify: {e}", positions[i]))?;
+        }
+        fragmented_time += timer.elapsed();
+
+        let timer = Instant::now();
+        verifier
+            .verify(&combined_proof, &evaluations, &positions)
+            .map_err(|e| format!("combined opening failed to verify: {e}"))?;
+        combined_time += timer.elapsed();
+
+        // Both arrangements must still reject a corrupted evaluation, otherwise the timings above
+        // could come from a short-circuited path
+        let corrupted = num_positions - 1;
+        let mut tampered = evaluations.clone();
+        tampered[corrupted * batch_size] += E::ONE;
+        if verifier
+            .verify(
+                &single_proofs[corrupted],
+                &tampered[corrupted * batch_size..],
+                &positions[corrupted..],
+            )
+            .is_ok()
+        {
+            return Err("fragmented verification accepted a corrupted evaluation".to_string());
+        }
+        if verifier
+            .verify(&combined_proof, &tampered, &positions)
+            .is_ok()
+        {
+            return Err("combined verification accepted a corrupted evaluation".to_string());
+        }
+    }
+
+    let fragmented_ms = fragmented_time.as_secs_f64() * 1000.0 / RUNS as f64;
+    let combined_ms = combined_time.as_secs_f64() * 1000.0 / RUNS as f64;
+    // K calls cost K * fixed + K * per-position, one call costs fixed + K * per-position
+    let fixed_cost_per_call_ms = if num_positions > 1 {
+        (fragmented_ms - combined_ms) / (num_positions - 1) as f64
+    } else {
+        0.0
+    };
+
+    Ok(FragmentationResult {
+        field_type: field_name.to_string(),
+        batch_size,
+        blowup_factor: options.blowup_factor(),
+        folding_factor: options.folding_factor(),
+        max_remainder_degree: options.remainder_max_degree(),
+        data_size_kb: data_size / 1024,
+        domain_size,
+        num_positions,
+        fragmented_verification_ms: fragmented_ms,
+        combined_verification_ms: combined_ms,
+        fixed_cost_per_call_ms,
+        fragmentation_penalty: fragmented_ms / combined_ms,
+        fragmented_proof_size_bytes: fragmented_proof_size / RUNS,
+        combined_proof_size_bytes: combined_proof_size / RUNS,
+    })
+}
+
+pub struct FragmentationBenchmarkConfig<'a> {
+    pub blowup_factor: usize,
+    pub folding_factor: usize,
+    pub max_remainder_degree: usize,
+    pub data_size: usize,
+    pub batch_size: usize,
+    pub position_counts: &'a [usize],
+    pub output_path: &'a str,
+}
+
+/// Runs the fragmentation experiment for every position count and both field types.
+pub fn run_fragmentation_benchmark(config: FragmentationBenchmarkConfig) -> Result<(), String> {
+    let options = FriOptions::new(
+        config.blowup_factor,
+        config.folding_factor,
+        config.max_remainder_degree,
+    );
+    let mut results = Vec::new();
+
+    println!("Running Frida verification fragmentation benchmark...");
+    println!(
+        "Parameters: blowup={}, folding={}, remainder={}, data={}KB, batch={}, positions={:?}",
+        config.blowup_factor,
+        config.folding_factor,
+        config.max_remainder_degree,
+        config.data_size / 1024,
+        config.batch_size,
+        config.position_counts
+    );
+
+    for &num_positions in config.position_counts {
+        results.push(
+            benchmark::<F64Element, Blake3F64>(
+                &options,
+                config.data_size,
+                config.batch_size,
+                num_positions,
+                field_names::F64,
+            )
+            .map_err(|e| format!("{} K={num_positions}: {e}", field_names::F64))?,
+        );
+        results.push(
+            benchmark::<F128Element, Blake3F128>(
+                &options,
+                config.data_size,
+                config.batch_size,
+                num_positions,
+                field_names::F128,
+            )
+            .map_err(|e| format!("{} K={num_positions}: {e}", field_names::F128))?,
+        );
+    }
+
+    common::save_results_with_header(
+        &results,
+        config.output_path,
+        &FragmentationResult::csv_header(),
+        |r| r.to_csv(),
+    )
+    .expect("Failed to save results");
+    println!("Fragmentation benchmark completed successfully");
+
+    println!("\nResults Summary (K single-position calls vs one K-position call):");
+    for result in &results {
+        println!(
+            "  {} K={}: {:.3} ms vs {:.3} ms ({:.2}x), fixed cost {:.3} ms per call",
+            result.field_type,
+            result.num_positions,
+            result.fragmented_verification_ms,
+            result.combined_verification_ms,
+            result.fragmentation_penalty,
+            result.fixed_cost_per_call_ms
+        );
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_spread_positions_are_distinct_and_in_range() {
+        for (count, domain_size) in [(1, 8), (3, 8), (8, 8), (32, 1024)] {
+            let mut positions = spread_positions(count, domain_size);
+            assert_eq!(positions.len(), count);
+            assert!(positions.iter().all(|&p| p < domain_size));
+            positions.sort();
+            positions.dedup();
+            assert_eq!(positions.len(), count);
+        }
+    }
+}
@@ -1,10 +1,19 @@
-use std::time::{Duration, Instant};
-use winter_crypto::ElementHasher;
+use serde::Serialize;
+use std::{
+    collections::BTreeMap,
+    fs,
+    io::Write,
+    time::{Duration, Instant},
+};
+use winter_crypto::{Digest, ElementHasher, Hasher};
 use winter_fri::FriOptions;
 use winter_math::FieldElement;
 use winter_rand_utils::rand_vector;
+use winter_utils::Serializable;
 
 use frida_poc::{
+    constants,
+    core::data::encoded_data_element_count,
     prover::{
         bench::{COMMIT_TIME, ERASURE_TIME},
         builder::FridaProverBuilder,
@@ -14,50 +23,515 @@ use frida_poc::{
 };
 
 use crate::common::{
-    self, field_names, get_standard_batch_sizes, get_standard_data_sizes, get_standard_fri_options,
-    get_standard_num_queries, Blake3F128, Blake3F64, F128Element, F64Element, RUNS,
+    self, data_size_for_encoded_size, field_names, get_standard_batch_sizes,
+    get_standard_encoded_sizes, get_standard_fri_options, get_standard_num_queries, Blake3F128,
+    Blake3F64, DataFootprint, F128Element, F64Element, HashFunction, InputSource, NamedHasher,
+    RunPolicy, Sha3F128, Sha3F64, SweepTotals, RUNS,
+};
+use crate::{
+    sampler,
+    stats::{self, calibrate_timer_overhead, MEDIAN_OF_MEANS_GROUPS},
 };
 
-#[derive(Debug)]
+/// Phases that record per-run timings and can carry a `--budget-check`.
+pub const BUDGET_PHASES: [&str; 4] = ["erasure", "commitment", "proof_32", "verification_32"];
+
+/// Number of configurations listed in the budget summary.
+const BUDGET_SUMMARY_LEN: usize = 5;
+
+/// Rolling-mean window and tolerance used to find where a profiled phase stabilizes.
+const PROFILE_WINDOW: usize = 10;
+const PROFILE_TOLERANCE_PCT: f64 = 2.0;
+
+/// Fewest data sizes a configuration needs in a sweep before its scaling is fitted.
+const SCALING_MIN_SIZES: usize = 3;
+/// How far the exponent between two neighbouring sizes may stray from the one fitted over the
+/// whole sweep before the configuration is flagged.
+const SCALING_JUMP: f64 = 0.5;
+
+/// Upper bound on the time any single run may spend in a phase, e.g. `proof_32<=3s`.
+#[derive(Debug, Clone, PartialEq)]
+pub struct PhaseBudget {
+    pub phase: &'static str,
+    pub limit_ms: f64,
+}
+
+/// Parses a `--budget-check` value of the form `<phase><=<duration>`.
+pub fn parse_phase_budget(value: &str) -> Result<PhaseBudget, String> {
+    let (phase, limit) = value
+        .split_once("<=")
+        .ok_or_else(|| format!("budget '{value}' must look like <phase><=<duration>"))?;
+    let phase = BUDGET_PHASES
+        .into_iter()
+        .find(|p| *p == phase.trim())
+        .ok_or_else(|| {
+            format!(
+                "unknown phase '{phase}', expected one of: {}",
+                BUDGET_PHASES.join(", ")
+            )
+        })?;
+    Ok(PhaseBudget {
+        phase,
+        limit_ms: common::parse_duration_ms(limit.trim())?,
+    })
+}
+
+/// Per-run timings, in milliseconds, of the phases listed in [`BUDGET_PHASES`].
+#[derive(Debug, Default)]
+struct PhaseSamples {
+    erasure: Vec<f64>,
+    commitment: Vec<f64>,
+    proof_32: Vec<f64>,
+    verification_32: Vec<f64>,
+    /// Positions opened by each run, for the per-run profile.
+    positions: Vec<Vec<usize>>,
+}
+
+impl PhaseSamples {
+    fn get(&self, phase: &str) -> &[f64] {
+        match phase {
+            "erasure" => &self.erasure,
+            "commitment" => &self.commitment,
+            "proof_32" => &self.proof_32,
+            "verification_32" => &self.verification_32,
+            _ => unreachable!("no per-run samples for phase {phase}"),
+        }
+    }
+}
+
+/// End-to-end figures worked out run by run from the phase samples, so that their medians and
+/// percentiles are over whole pipelines rather than ratios of phase averages.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Serialize)]
+struct PipelineFigures {
+    /// Erasure coding, commitment and a 32-position proof of one run.
+    pipeline_latency_ms: f64,
+    pipeline_latency_p50_ms: f64,
+    pipeline_latency_p99_ms: f64,
+    /// Median over runs of the raw, unpadded data committed and proven per second.
+    prover_throughput_mb_s: f64,
+    /// Median over runs of the positions verified per second in the 32-position verification.
+    verifier_samples_per_s: f64,
+}
+
+impl PipelineFigures {
+    fn from_samples(samples: &PhaseSamples, data_bytes: usize) -> Self {
+        let latency = samples
+            .erasure
+            .iter()
+            .zip(&samples.commitment)
+            .zip(&samples.proof_32)
+            .map(|((erasure, commitment), proof)| erasure + commitment + proof)
+            .collect::<Vec<_>>();
+        Self {
+            pipeline_latency_ms: stats::mean(&latency),
+            pipeline_latency_p50_ms: stats::percentile(&latency, 50.0),
+            pipeline_latency_p99_ms: stats::percentile(&latency, 99.0),
+            prover_throughput_mb_s: median_rate(data_bytes as f64 / (1024.0 * 1024.0), &latency),
+            verifier_samples_per_s: median_rate(32.0, &samples.verification_32),
+        }
+    }
+}
+
+/// Median over runs of `amount` per second, skipping runs too short for the timer to register.
+fn median_rate(amount: f64, samples_ms: &[f64]) -> f64 {
+    let rates = samples_ms
+        .iter()
+        .filter(|&&ms| ms > 0.0)
+        .map(|ms| amount * 1000.0 / ms)
+        .collect::<Vec<_>>();
+    stats::percentile(&rates, 50.0)
+}
+
+/// Distribution of a phase's per-run times, so a single slow run shows up instead of only
+/// nudging the mean.
+#[derive(Debug, Clone, Copy, Serialize)]
+struct PhaseSpread {
+    min_ms: f64,
+    p50_ms: f64,
+    p90_ms: f64,
+    max_ms: f64,
+    p99_ms: f64,
+    stddev_ms: f64,
+}
+
+impl PhaseSpread {
+    fn from_samples(samples: &[f64]) -> Self {
+        PhaseSpread {
+            min_ms: stats::min(samples),
+            p50_ms: stats::percentile(samples, 50.0),
+            p90_ms: stats::percentile(samples, 90.0),
+            max_ms: stats::max(samples),
+            p99_ms: stats::percentile(samples, 99.0),
+            stddev_ms: stats::stddev(samples),
+        }
+    }
+}
+
+#[derive(Debug, Serialize)]
 struct FridaBenchmarkResult {
     field_type: String,
+    hash: &'static str,
     batch_size: usize,
     blowup_factor: usize,
     folding_factor: usize,
     max_remainder_degree: usize,
     data_size_kb: usize,
+    #[serde(flatten)]
+    footprint: DataFootprint,
     num_queries: usize,
+    domain_size: usize,
+    log2_domain_size: u32,
+    extension_factor: f64,
+    runs: usize,
+    warmup_runs: usize,
     erasure_time_ms: f64,
+    erasure_spread: PhaseSpread,
     commitment_time_ms: f64,
+    commitment_spread: PhaseSpread,
     proof_time_1_ms: f64,
     proof_time_16_ms: f64,
     proof_time_32_ms: f64,
+    proof_time_32_median_of_means_ms: f64,
+    proof_time_32_ci_pct: f64,
+    proof_32_spread: PhaseSpread,
     verification_setup_ms: f64,
     verification_1_ms: f64,
     verification_16_ms: f64,
     verification_32_ms: f64,
+    verification_32_spread: PhaseSpread,
     commitment_size_bytes: usize,
     proof_size_1_bytes: usize,
     proof_size_16_bytes: usize,
     proof_size_32_bytes: usize,
+    #[serde(flatten)]
+    pipeline: PipelineFigures,
+    harness_overhead_ms: f64,
+    harness_overhead_pct: f64,
+    low_confidence_phases: Vec<&'static str>,
+    budget_exceeded: Vec<&'static str>,
+    /// Hash of the first run's commitment and 32-position proof, for seeded input only.
+    artifact_hash: Option<String>,
+    #[serde(skip)]
+    samples: PhaseSamples,
+    #[serde(skip)]
+    work: SweepTotals,
 }
 
 impl FridaBenchmarkResult {
     fn csv_header() -> String {
-        "field_type,batch_size,blowup_factor,folding_factor,max_remainder_degree,data_size_kb,num_queries,erasure_time_ms,commitment_time_ms,proof_time_1_ms,proof_time_16_ms,proof_time_32_ms,verification_setup_ms,verification_1_ms,verification_16_ms,verification_32_ms,commitment_size_bytes,proof_size_1_bytes,proof_size_16_bytes,proof_size_32_bytes".to_string()
+        "field_type,hash,batch_size,blowup_factor,folding_factor,max_remainder_degree,data_size_kb,data_size_bytes,encoded_size_bytes,padded_size_bytes,padding_overhead_pct,systematic_evaluations,padding_evaluations,parity_evaluations,num_queries,domain_size,log2_domain_size,extension_factor,runs,warmup_runs,erasure_time_ms,erasure_time_min_ms,erasure_time_p50_ms,erasure_time_p90_ms,erasure_time_max_ms,erasure_time_p99_ms,erasure_time_stddev_ms,commitment_time_ms,commitment_time_min_ms,commitment_time_p50_ms,commitment_time_p90_ms,commitment_time_max_ms,commitment_time_p99_ms,commitment_time_stddev_ms,proof_time_1_ms,proof_time_16_ms,proof_time_32_ms,proof_time_32_median_of_means_ms,proof_time_32_ci_pct,proof_time_32_min_ms,proof_time_32_p50_ms,proof_time_32_p90_ms,proof_time_32_max_ms,proof_time_32_p99_ms,proof_time_32_stddev_ms,verification_setup_ms,verification_1_ms,verification_16_ms,verification_32_ms,verification_32_min_ms,verification_32_p50_ms,verification_32_p90_ms,verification_32_max_ms,verification_32_p99_ms,verification_32_stddev_ms,commitment_size_bytes,proof_size_1_bytes,proof_size_16_bytes,proof_size_32_bytes,pipeline_latency_ms,pipeline_latency_p50_ms,pipeline_latency_p99_ms,prover_throughput_mb_s,verifier_samples_per_s,harness_overhead_ms,harness_overhead_pct,low_confidence_phases,budget_exceeded,artifact_hash".to_string()
     }
 
     fn to_csv(&self) -> String {
         format!(
-            "{},{},{},{},{},{},{},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{},{},{},{}",
-            self.field_type, self.batch_size, self.blowup_factor, self.folding_factor,
-            self.max_remainder_degree, self.data_size_kb, self.num_queries,
-            self.erasure_time_ms, self.commitment_time_ms,
-            self.proof_time_1_ms, self.proof_time_16_ms, self.proof_time_32_ms,
-            self.verification_setup_ms, self.verification_1_ms, self.verification_16_ms, self.verification_32_ms,
-            self.commitment_size_bytes, self.proof_size_1_bytes, self.proof_size_16_bytes, self.proof_size_32_bytes
+            "{},{},{},{},{},{},{},{},{},{},{:.1},{},{},{},{},{},{},{:.3},{},{},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.1},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{:.3},{},{},{},{},{:.3},{:.3},{:.3},{:.3},{:.1},{:.3},{:.1},{},{},{}",
+            self.field_type, self.hash, self.batch_size, self.blowup_factor, self.folding_factor,
+            self.max_remainder_degree, self.data_size_kb, self.footprint.data_size_bytes,
+            self.footprint.encoded_size_bytes, self.footprint.padded_size_bytes,
+            self.footprint.padding_overhead_pct, self.footprint.systematic_evaluations,
+            self.footprint.padding_evaluations, self.footprint.parity_evaluations,
+            self.num_queries, self.domain_size,
+            self.log2_domain_size, self.extension_factor, self.runs, self.warmup_runs,
+            self.erasure_time_ms, self.erasure_spread.min_ms, self.erasure_spread.p50_ms,
+            self.erasure_spread.p90_ms, self.erasure_spread.max_ms, self.erasure_spread.p99_ms,
+            self.erasure_spread.stddev_ms, self.commitment_time_ms, self.commitment_spread.min_ms,
+            self.commitment_spread.p50_ms, self.commitment_spread.p90_ms,
+            self.commitment_spread.max_ms, self.commitment_spread.p99_ms,
+            self.commitment_spread.stddev_ms, self.proof_time_1_ms, self.proof_time_16_ms,
+            self.proof_time_32_ms, self.proof_time_32_median_of_means_ms, self.proof_time_32_ci_pct,
+            self.proof_32_spread.min_ms, self.proof_32_spread.p50_ms, self.proof_32_spread.p90_ms,
+            self.proof_32_spread.max_ms, self.proof_32_spread.p99_ms,
+            self.proof_32_spread.stddev_ms, self.verification_setup_ms, self.verification_1_ms,
+            self.verification_16_ms, self.verification_32_ms, self.verification_32_spread.min_ms,
+            self.verification_32_spread.p50_ms, self.verification_32_spread.p90_ms,
+            self.verification_32_spread.max_ms, self.verification_32_spread.p99_ms,
+            self.verification_32_spread.stddev_ms, self.commitment_size_bytes,
+            self.proof_size_1_bytes, self.proof_size_16_bytes, self.proof_size_32_bytes,
+            self.pipeline.pipeline_latency_ms, self.pipeline.pipeline_latency_p50_ms,
+            self.pipeline.pipeline_latency_p99_ms, self.pipeline.prover_throughput_mb_s,
+            self.pipeline.verifier_samples_per_s, self.harness_overhead_ms, self.harness_overhead_pct,
+            self.low_confidence_phases.join(";"), self.budget_exceeded.join(";"),
+            self.artifact_hash.as_deref().unwrap_or("")
         )
     }
+
+    /// Flags every timed phase whose average is too close to the timer overhead to be trusted.
+    fn mark_low_confidence(&mut self, timer_overhead: Duration) {
+        self.low_confidence_phases = stats::low_confidence_phases(
+            &[
+                ("erasure", self.erasure_time_ms),
+                ("commitment", self.commitment_time_ms),
+                ("proof_1", self.proof_time_1_ms),
+                ("proof_16", self.proof_time_16_ms),
+                ("proof_32", self.proof_time_32_ms),
+                ("verification_setup", self.verification_setup_ms),
+                ("verification_1", self.verification_1_ms),
+                ("verification_16", self.verification_16_ms),
+                ("verification_32", self.verification_32_ms),
+            ],
+            timer_overhead,
+        );
+    }
+
+    fn spread(&self, phase: &str) -> PhaseSpread {
+        match phase {
+            "erasure" => self.erasure_spread,
+            "commitment" => self.commitment_spread,
+            "proof_32" => self.proof_32_spread,
+            "verification_32" => self.verification_32_spread,
+            _ => unreachable!("no per-run samples for phase {phase}"),
+        }
+    }
+
+    /// Records every budgeted phase whose slowest run went over its budget.
+    fn check_budgets(&mut self, budgets: &[PhaseBudget]) {
+        self.budget_exceeded = budgets
+            .iter()
+            .filter(|b| self.spread(b.phase).max_ms > b.limit_ms)
+            .map(|b| b.phase)
+            .collect();
+    }
+
+    /// Largest fraction of any budget taken by the slowest run.
+    fn budget_usage(&self, budgets: &[PhaseBudget]) -> f64 {
+        budgets
+            .iter()
+            .map(|b| self.spread(b.phase).max_ms / b.limit_ms)
+            .fold(0.0, f64::max)
+    }
+}
+
+/// Checks every result against `budgets` and prints the configurations closest to their budgets.
+/// Returns false if any single run exceeded a budget.
+fn apply_budgets(results: &mut [FridaBenchmarkResult], budgets: &[PhaseBudget]) -> bool {
+    if budgets.is_empty() {
+        return true;
+    }
+    for result in results.iter_mut() {
+        result.check_budgets(budgets);
+    }
+
+    let exceeded = results
+        .iter()
+        .filter(|r| !r.budget_exceeded.is_empty())
+        .count();
+    println!(
+        "Budget check: {exceeded} of {} configurations exceeded a budget",
+        results.len()
+    );
+
+    let mut by_usage = results
+        .iter()
+        .map(|r| (r.budget_usage(budgets), r))
+        .collect::<Vec<_>>();
+    by_usage.sort_by(|a, b| b.0.total_cmp(&a.0));
+    println!("Closest to budget (slowest run as % of budget):");
+    for (usage, r) in by_usage.into_iter().take(BUDGET_SUMMARY_LEN) {
+        println!(
+            "  {:6.1}%  {} fri=({},{},{}) batch={} data={}KB queries={}{}",
+            usage * 100.0,
+            r.field_type,
+            r.blowup_factor,
+            r.folding_factor,
+            r.max_remainder_degree,
+            r.batch_size,
+            r.data_size_kb,
+            r.num_queries,
+            if r.budget_exceeded.is_empty() {
+                String::new()
+            } else {
+                format!(" EXCEEDED: {}", r.budget_exceeded.join(", "))
+            }
+        );
+    }
+
+    exceeded == 0
+}
+
+/// How the median time of one phase of one configuration grows with the data size over a sweep.
+#[derive(Debug, Serialize)]
+struct ScalingFit {
+    field_type: String,
+    hash: &'static str,
+    blowup_factor: usize,
+    folding_factor: usize,
+    max_remainder_degree: usize,
+    batch_size: usize,
+    num_queries: usize,
+    phase: &'static str,
+    data_sizes: usize,
+    /// Exponent of the data size, e.g. 1.0 for linear growth.
+    exponent: f64,
+    r_squared: f64,
+    /// Exponent between the two neighbouring sizes that strays furthest from `exponent`.
+    worst_step_exponent: f64,
+    jump: bool,
+}
+
+/// Fits, per configuration and phase, the exponent with which the median time grows with the
+/// data size. Configurations swept over fewer than [`SCALING_MIN_SIZES`] sizes are left out.
+fn fit_scaling(results: &[FridaBenchmarkResult]) -> Vec<ScalingFit> {
+    let mut configurations = BTreeMap::<_, Vec<&FridaBenchmarkResult>>::new();
+    for r in results {
+        let key = (
+            r.field_type.as_str(),
+            r.hash,
+            r.blowup_factor,
+            r.folding_factor,
+            r.max_remainder_degree,
+            r.batch_size,
+            r.num_queries,
+        );
+        configurations.entry(key).or_default().push(r);
+    }
+
+    let mut fits = Vec::new();
+    for (key, mut sized) in configurations {
+        sized.sort_by_key(|r| r.footprint.data_size_bytes);
+        sized.dedup_by_key(|r| r.footprint.data_size_bytes);
+        if sized.len() < SCALING_MIN_SIZES {
+            continue;
+        }
+        for phase in BUDGET_PHASES {
+            let points = sized
+                .iter()
+                .map(|r| {
+                    (
+                        r.footprint.data_size_bytes as f64,
+                        stats::percentile(r.samples.get(phase), 50.0),
+                    )
+                })
+                .collect::<Vec<_>>();
+            let Some(fit) = stats::power_fit(&points) else {
+                continue;
+            };
+            let worst_step_exponent = points
+                .windows(2)
+                .filter_map(stats::power_fit)
+                .map(|step| step.exponent)
+                .max_by(|a, b| {
+                    (a - fit.exponent)
+                        .abs()
+                        .total_cmp(&(b - fit.exponent).abs())
+                })
+                .unwrap_or(fit.exponent);
+            let (field_type, hash, blowup, folding, remainder, batch_size, num_queries) = key;
+            fits.push(ScalingFit {
+                field_type: field_type.to_string(),
+                hash,
+                blowup_factor: blowup,
+                folding_factor: folding,
+                max_remainder_degree: remainder,
+                batch_size,
+                num_queries,
+                phase,
+                data_sizes: points.len(),
+                exponent: fit.exponent,
+                r_squared: fit.r_squared,
+                worst_step_exponent,
+                jump: (worst_step_exponent - fit.exponent).abs() > SCALING_JUMP,
+            });
+        }
+    }
+    fits
+}
+
+/// Prints the range of fitted exponents per phase and every configuration whose scaling jumps.
+fn print_scaling(fits: &[ScalingFit]) {
+    if fits.is_empty() {
+        println!("Scaling: no configuration was run at {SCALING_MIN_SIZES} or more data sizes");
+        return;
+    }
+    println!("Scaling with data size (exponent of the median time, least squares on log-log):");
+    for phase in BUDGET_PHASES {
+        let exponents = fits
+            .iter()
+            .filter(|f| f.phase == phase)
+            .map(|f| f.exponent)
+            .collect::<Vec<_>>();
+        if !exponents.is_empty() {
+            println!(
+                "  {phase}: n^{:.2} median, n^{:.2} to n^{:.2}",
+                stats::percentile(&exponents, 50.0),
+                stats::min(&exponents),
+                stats::max(&exponents)
+            );
+        }
+    }
+    for f in fits.iter().filter(|f| f.jump) {
+        println!(
+            "  jump: {} fri=({},{},{}) batch={} queries={} {}: n^{:.2} overall (R² {:.3}), n^{:.2} between two sizes",
+            f.field_type,
+            f.blowup_factor,
+            f.folding_factor,
+            f.max_remainder_degree,
+            f.batch_size,
+            f.num_queries,
+            f.phase,
+            f.exponent,
+            f.r_squared,
+            f.worst_step_exponent
+        );
+    }
+}
+
+/// Writes every run of every result as one row, so warm-up effects can be plotted per phase.
+fn write_profile(results: &[FridaBenchmarkResult], output_path: &str) -> std::io::Result<()> {
+    common::ensure_output_dir(output_path)?;
+
+    let mut file = fs::File::create(output_path)?;
+    writeln!(
+        file,
+        "field_type,artifact_hash,run,positions,{}",
+        BUDGET_PHASES.map(|p| format!("{p}_ms")).join(",")
+    )?;
+    for result in results {
+        let artifact_hash = result.artifact_hash.as_deref().unwrap_or("");
+        for run in 0..result.runs {
+            let positions = result.samples.positions[run]
+                .iter()
+                .map(usize::to_string)
+                .collect::<Vec<_>>();
+            let times = BUDGET_PHASES.map(|p| format!("{:.3}", result.samples.get(p)[run]));
+            writeln!(
+                file,
+                "{},{artifact_hash},{run},{},{}",
+                result.field_type,
+                positions.join(";"),
+                times.join(",")
+            )?;
+        }
+    }
+    Ok(())
+}
+
+/// Prints the run at which each phase's rolling mean stabilizes and the resulting warmup count.
+fn print_warmup_recommendation(results: &[FridaBenchmarkResult]) {
+    println!(
+        "Stabilization (rolling mean of {PROFILE_WINDOW} runs within {PROFILE_TOLERANCE_PCT}% of the final one):"
+    );
+    for result in results {
+        let mut warmup = Some(0);
+        for phase in BUDGET_PHASES {
+            let index = stats::stabilization_index(
+                result.samples.get(phase),
+                PROFILE_WINDOW,
+                PROFILE_TOLERANCE_PCT,
+            );
+            match index {
+                Some(i) => println!("  {} {phase}: stable from run {i}", result.field_type),
+                None => println!("  {} {phase}: did not stabilize", result.field_type),
+            }
+            warmup = warmup.zip(index).map(|(w, i)| usize::max(w, i));
+        }
+        match warmup {
+            Some(w) => println!("  {}: recommended warmup {w} runs", result.field_type),
+            None => println!(
+                "  {}: no recommendation, increase --profile-runs",
+                result.field_type
+            ),
+        }
+    }
 }
 
 fn prepare_verifier<E: FieldElement, H: ElementHasher<BaseField = E::BaseField>>(
@@ -65,20 +539,155 @@ fn prepare_verifier<E: FieldElement, H: ElementHasher<BaseField = E::BaseField>>
     folding_factor: usize,
     remainder_max_degree: usize,
     com: Commitment<H>,
-) -> FridaDasVerifier<E, H, H> {
+) -> Result<FridaDasVerifier<E, H, H>, String> {
     let options = FriOptions::new(blowup_factor, folding_factor, remainder_max_degree);
-    FridaDasVerifier::new(com, options).unwrap().0
+    FridaDasVerifier::new(com, options)
+        .map(|(verifier, _)| verifier)
+        .map_err(|e| format!("verifier setup failed: {e}"))
+}
+
+/// How each configuration is run.
+#[derive(Debug, Clone, Copy)]
+struct RunPlan {
+    policy: RunPolicy,
+    /// Untimed runs before the measured ones.
+    warmup: usize,
+    input: InputSource,
+}
+
+/// Identifies a configuration in warnings and errors.
+fn config_label(
+    options: &FriOptions,
+    field_name: &str,
+    batch_size: usize,
+    data_size: usize,
+    num_queries: usize,
+) -> String {
+    format!(
+        "{field_name} fri=({},{},{}) batch={batch_size} data={}KB queries={num_queries}",
+        options.blowup_factor(),
+        options.folding_factor(),
+        options.remainder_max_degree(),
+        data_size / 1024
+    )
+}
+
+/// Draws the 32 positions a run opens. Seeded runs use the shared sampler, keyed by the seed, the
+/// commitment and the run index as client id, so that other implementations can draw the same.
+/// The sampler draws distinct positions, so a domain smaller than 32 is drawn whole and repeated.
+fn draw_positions<H: ElementHasher>(
+    input: InputSource,
+    run: usize,
+    com: &Commitment<H>,
+) -> Vec<usize> {
+    match input {
+        InputSource::Seeded(seed) => sampler::sample_positions(
+            seed,
+            &sampler::blob_id(&com.to_bytes()),
+            run as u64,
+            com.domain_size,
+            usize::min(32, com.domain_size),
+        )
+        .into_iter()
+        .cycle()
+        .take(32)
+        .collect(),
+        InputSource::Random => rand_vector::<u64>(32)
+            .into_iter()
+            .map(|v| (v as usize) % com.domain_size)
+            .collect(),
+    }
+}
+
+fn hex_digest<H: Hasher>(bytes: &[u8]) -> String {
+    H::hash(bytes)
+        .as_bytes()
+        .map(|b| format!("{b:02x}"))
+        .concat()
+}
+
+/// Hashes a run's commitment together with its 32-position opening proof. Only seeded input
+/// commits to the same data and opens the same positions on every run, so with random input
+/// there is nothing to compare and no hash.
+fn seeded_artifact_hash<H: ElementHasher>(
+    input: InputSource,
+    com: &Commitment<H>,
+    proof_32: &impl Serializable,
+) -> Option<String> {
+    match input {
+        InputSource::Seeded(_) => Some(hex_digest::<H>(
+            &[com.to_bytes(), proof_32.to_bytes()].concat(),
+        )),
+        InputSource::Random => None,
+    }
+}
+
+/// Runs the whole pipeline `runs` times without timing anything, so that cold caches and page
+/// faults on first-time allocations are paid for before the measured runs start.
+fn warm_up<E, H>(
+    options: &FriOptions,
+    data_size: usize,
+    batch_size: usize,
+    num_queries: usize,
+    runs: usize,
+) -> Result<(), String>
+where
+    E: FieldElement,
+    H: ElementHasher<BaseField = E::BaseField>,
+{
+    for _ in 0..runs {
+        let data_list = (0..batch_size)
+            .map(|_| rand_vector::<u8>(data_size))
+            .collect::<Vec<_>>();
+        let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
+        let (com, prover) = if batch_size > 1 {
+            prover_builder.commit_and_prove_batch(&data_list, num_queries)
+        } else {
+            prover_builder.commit_and_prove(&data_list[0], num_queries)
+        }
+        .map_err(|e| format!("warm-up commitment failed: {e}"))?;
+
+        let positions = rand_vector::<u64>(32)
+            .into_iter()
+            .map(|v| (v as usize) % com.domain_size)
+            .collect::<Vec<_>>();
+        let evaluations = get_evaluations_from_positions(
+            prover.get_first_layer_evaluations(),
+            &positions,
+            batch_size,
+            com.domain_size,
+            options.folding_factor(),
+        );
+        let proof = prover.open(&positions);
+        let verifier = prepare_verifier::<E, H>(
+            options.blowup_factor(),
+            options.folding_factor(),
+            options.remainder_max_degree(),
+            com,
+        )?;
+        verifier
+            .verify(&proof, &evaluations, &positions)
+            .map_err(|e| format!("warm-up verification failed: {e}"))?;
+    }
+
+    // The prover accumulates into these, so drop what the warm-up runs recorded
+    unsafe {
+        ERASURE_TIME = None;
+        COMMIT_TIME = None;
+    }
+    Ok(())
 }
 
 fn benchmark_non_batched<E, H>(
     options: FriOptions,
     data_size: usize,
     num_queries: usize,
+    plan: RunPlan,
     field_name: &str,
-) -> FridaBenchmarkResult
+) -> Result<FridaBenchmarkResult, String>
 where
     E: FieldElement,
-    H: ElementHasher<BaseField = E::BaseField>,
+    H: ElementHasher<BaseField = E::BaseField> + NamedHasher,
 {
     let mut total_erasure_time = Duration::ZERO;
     let mut total_commitment_time = Duration::ZERO;
@@ -91,26 +700,43 @@ where
     );
     let mut total_commitment_size = 0;
     let mut total_proof_sizes = (0, 0, 0);
+    let mut samples = PhaseSamples::default();
+    let mut domain_size = 0;
+    let mut artifact_hash = None;
 
-    for _ in 0..RUNS {
-        let data = rand_vector::<u8>(data_size);
+    let label = config_label(&options, field_name, 1, data_size, num_queries);
+    common::check_domain_fits::<E>(data_size, 1, options.blowup_factor())
+        .map_err(|e| format!("{label}: {e}"))?;
+    warm_up::<E, H>(&options, data_size, 1, num_queries, plan.warmup)
+        .map_err(|e| format!("{label}: {e}"))?;
+    let config_start = Instant::now();
+    while plan.policy.needs_more_runs(&samples.proof_32) {
+        let data = plan.input.blob(samples.proof_32.len(), data_size);
         let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
 
-        let (com, prover) = prover_builder.commit_and_prove(&data, num_queries).unwrap();
+        let (com, prover) = prover_builder
+            .commit_and_prove(&data, num_queries)
+            .map_err(|e| format!("{label}: commitment failed: {e}"))?;
+        domain_size = com.domain_size;
 
-        unsafe {
-            total_erasure_time += ERASURE_TIME.unwrap_or_default();
-            total_commitment_time += COMMIT_TIME.unwrap_or_default();
+        let (erasure_time, commit_time) = unsafe {
+            let times = (
+                ERASURE_TIME.unwrap_or_default(),
+                COMMIT_TIME.unwrap_or_default(),
+            );
             ERASURE_TIME = None;
             COMMIT_TIME = None;
-        }
+            times
+        };
+        total_erasure_time += erasure_time;
+        total_commitment_time += commit_time;
+        samples.erasure.push(erasure_time.as_secs_f64() * 1000.0);
+        samples.commitment.push(commit_time.as_secs_f64() * 1000.0);
 
         total_commitment_size += com.proof.size() + com.roots.len() * 32 + 3;
 
-        let positions = rand_vector::<u64>(32)
-            .into_iter()
-            .map(|v| (v as usize) % com.domain_size)
-            .collect::<Vec<_>>();
+        let positions = draw_positions(plan.input, samples.proof_32.len(), &com);
+        samples.positions.push(positions.clone());
 
         let evaluations = positions
             .iter()
@@ -136,7 +762,12 @@ where
         let timer = Instant::now();
         let proof_32 = prover.open(&positions);
         total_proof_sizes.2 += proof_32.size();
-        total_proof_times.2 += timer.elapsed();
+        let proof_32_time = timer.elapsed();
+        total_proof_times.2 += proof_32_time;
+        samples.proof_32.push(proof_32_time.as_secs_f64() * 1000.0);
+        if artifact_hash.is_none() {
+            artifact_hash = seeded_artifact_hash(plan.input, &com, &proof_32);
+        }
 
         // Benchmark verification
         let timer = Instant::now();
@@ -145,50 +776,100 @@ where
             options.folding_factor(),
             options.remainder_max_degree(),
             com,
-        );
+        )
+        .map_err(|e| format!("{label}: {e}"))?;
         total_verify_times.0 += timer.elapsed();
 
         let timer = Instant::now();
         verifier
             .verify(&proof_1, &evaluations[0..1], &positions[0..1])
-            .unwrap();
+            .map_err(|e| format!("{label}: verification of 1 position failed: {e}"))?;
         total_verify_times.1 += timer.elapsed();
 
         let timer = Instant::now();
         verifier
             .verify(&proof_16, &evaluations[0..16], &positions[0..16])
-            .unwrap();
+            .map_err(|e| format!("{label}: verification of 16 positions failed: {e}"))?;
         total_verify_times.2 += timer.elapsed();
 
         let timer = Instant::now();
         verifier
             .verify(&proof_32, &evaluations, &positions)
-            .unwrap();
-        total_verify_times.3 += timer.elapsed();
+            .map_err(|e| format!("{label}: verification of 32 positions failed: {e}"))?;
+        let verify_32_time = timer.elapsed();
+        total_verify_times.3 += verify_32_time;
+        samples
+            .verification_32
+            .push(verify_32_time.as_secs_f64() * 1000.0);
     }
 
-    FridaBenchmarkResult {
+    let timed = total_erasure_time
+        + total_commitment_time
+        + total_proof_times.0
+        + total_proof_times.1
+        + total_proof_times.2
+        + total_verify_times.0
+        + total_verify_times.1
+        + total_verify_times.2
+        + total_verify_times.3;
+    let runs = samples.proof_32.len();
+    let (log2_domain_size, extension_factor) =
+        common::domain_geometry(domain_size, encoded_data_element_count::<E>(data_size));
+    let (harness_overhead_ms, harness_overhead_pct) =
+        common::harness_overhead(config_start.elapsed(), timed, runs);
+    common::warn_on_overhead(&label, harness_overhead_pct);
+
+    Ok(FridaBenchmarkResult {
         field_type: field_name.to_string(),
+        hash: H::HASH.name(),
         batch_size: 1,
         blowup_factor: options.blowup_factor(),
         folding_factor: options.folding_factor(),
         max_remainder_degree: options.remainder_max_degree(),
         data_size_kb: data_size / 1024,
+        footprint: DataFootprint::new::<E>(data_size, domain_size, options.blowup_factor()),
         num_queries,
-        erasure_time_ms: total_erasure_time.as_secs_f64() * 1000.0 / RUNS as f64,
-        commitment_time_ms: total_commitment_time.as_secs_f64() * 1000.0 / RUNS as f64,
-        proof_time_1_ms: total_proof_times.0.as_secs_f64() * 1000.0 / RUNS as f64,
-        proof_time_16_ms: total_proof_times.1.as_secs_f64() * 1000.0 / RUNS as f64,
-        proof_time_32_ms: total_proof_times.2.as_secs_f64() * 1000.0 / RUNS as f64,
-        verification_setup_ms: total_verify_times.0.as_secs_f64() * 1000.0 / RUNS as f64,
-        verification_1_ms: total_verify_times.1.as_secs_f64() * 1000.0 / RUNS as f64,
-        verification_16_ms: total_verify_times.2.as_secs_f64() * 1000.0 / RUNS as f64,
-        verification_32_ms: total_verify_times.3.as_secs_f64() * 1000.0 / RUNS as f64,
-        commitment_size_bytes: total_commitment_size / RUNS,
-        proof_size_1_bytes: total_proof_sizes.0 / RUNS,
-        proof_size_16_bytes: total_proof_sizes.1 / RUNS,
-        proof_size_32_bytes: total_proof_sizes.2 / RUNS,
-    }
+        domain_size,
+        log2_domain_size,
+        extension_factor,
+        runs,
+        warmup_runs: plan.warmup,
+        erasure_time_ms: total_erasure_time.as_secs_f64() * 1000.0 / runs as f64,
+        erasure_spread: PhaseSpread::from_samples(&samples.erasure),
+        commitment_time_ms: total_commitment_time.as_secs_f64() * 1000.0 / runs as f64,
+        commitment_spread: PhaseSpread::from_samples(&samples.commitment),
+        proof_time_1_ms: total_proof_times.0.as_secs_f64() * 1000.0 / runs as f64,
+        proof_time_16_ms: total_proof_times.1.as_secs_f64() * 1000.0 / runs as f64,
+        proof_time_32_ms: total_proof_times.2.as_secs_f64() * 1000.0 / runs as f64,
+        proof_time_32_median_of_means_ms: stats::median_of_means(
+            &samples.proof_32,
+            MEDIAN_OF_MEANS_GROUPS,
+        ),
+        proof_time_32_ci_pct: stats::ci_relative_pct(&samples.proof_32),
+        proof_32_spread: PhaseSpread::from_samples(&samples.proof_32),
+        verification_setup_ms: total_verify_times.0.as_secs_f64() * 1000.0 / runs as f64,
+        verification_1_ms: total_verify_times.1.as_secs_f64() * 1000.0 / runs as f64,
+        verification_16_ms: total_verify_times.2.as_secs_f64() * 1000.0 / runs as f64,
+        verification_32_ms: total_verify_times.3.as_secs_f64() * 1000.0 / runs as f64,
+        verification_32_spread: PhaseSpread::from_samples(&samples.verification_32),
+        commitment_size_bytes: total_commitment_size / runs,
+        proof_size_1_bytes: total_proof_sizes.0 / runs,
+        proof_size_16_bytes: total_proof_sizes.1 / runs,
+        proof_size_32_bytes: total_proof_sizes.2 / runs,
+        pipeline: PipelineFigures::from_samples(&samples, data_size),
+        harness_overhead_ms,
+        harness_overhead_pct,
+        low_confidence_phases: Vec::new(),
+        budget_exceeded: Vec::new(),
+        artifact_hash,
+        samples,
+        work: SweepTotals {
+            data_bytes: runs * data_size,
+            // The commitment carries a proof, plus three openings per run
+            proofs: runs * 4,
+            verifications: runs * 3,
+        },
+    })
 }
 
 fn benchmark_batched<E, H>(
@@ -196,11 +877,12 @@ fn benchmark_batched<E, H>(
     data_size: usize,
     batch_size: usize,
     num_queries: usize,
+    plan: RunPlan,
     field_name: &str,
-) -> FridaBenchmarkResult
+) -> Result<FridaBenchmarkResult, String>
 where
     E: FieldElement,
-    H: ElementHasher<BaseField = E::BaseField>,
+    H: ElementHasher<BaseField = E::BaseField> + NamedHasher,
 {
     let mut total_erasure_time = Duration::ZERO;
     let mut total_commitment_time = Duration::ZERO;
@@ -213,31 +895,46 @@ where
     );
     let mut total_commitment_size = 0;
     let mut total_proof_sizes = (0, 0, 0);
+    let mut samples = PhaseSamples::default();
+    let mut domain_size = 0;
+    let mut artifact_hash = None;
 
-    for _ in 0..RUNS {
-        let mut data_list = vec![];
-        for _ in 0..batch_size {
-            data_list.push(rand_vector::<u8>(data_size));
-        }
+    let label = config_label(&options, field_name, batch_size, data_size, num_queries);
+    common::check_domain_fits::<E>(data_size, batch_size, options.blowup_factor())
+        .map_err(|e| format!("{label}: {e}"))?;
+    warm_up::<E, H>(&options, data_size, batch_size, num_queries, plan.warmup)
+        .map_err(|e| format!("{label}: {e}"))?;
+    let config_start = Instant::now();
+    while plan.policy.needs_more_runs(&samples.proof_32) {
+        let first_blob = samples.proof_32.len() * batch_size;
+        let data_list = (first_blob..first_blob + batch_size)
+            .map(|index| plan.input.blob(index, data_size))
+            .collect::<Vec<_>>();
 
         let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
         let (com, prover) = prover_builder
             .commit_and_prove_batch(&data_list, num_queries)
-            .unwrap();
+            .map_err(|e| format!("{label}: commitment failed: {e}"))?;
+        domain_size = com.domain_size;
 
-        unsafe {
-            total_erasure_time += ERASURE_TIME.unwrap_or_default();
-            total_commitment_time += COMMIT_TIME.unwrap_or_default();
+        let (erasure_time, commit_time) = unsafe {
+            let times = (
+                ERASURE_TIME.unwrap_or_default(),
+                COMMIT_TIME.unwrap_or_default(),
+            );
             ERASURE_TIME = None;
             COMMIT_TIME = None;
-        }
+            times
+        };
+        total_erasure_time += erasure_time;
+        total_commitment_time += commit_time;
+        samples.erasure.push(erasure_time.as_secs_f64() * 1000.0);
+        samples.commitment.push(commit_time.as_secs_f64() * 1000.0);
 
         total_commitment_size += com.proof.size() + com.roots.len() * 32 + 3;
 
-        let positions = rand_vector::<u64>(32)
-            .into_iter()
-            .map(|v| (v as usize) % com.domain_size)
-            .collect::<Vec<_>>();
+        let positions = draw_positions(plan.input, samples.proof_32.len(), &com);
+        samples.positions.push(positions.clone());
 
         let evaluations = get_evaluations_from_positions(
             prover.get_first_layer_evaluations(),
@@ -261,7 +958,12 @@ where
         let timer = Instant::now();
         let proof_32 = prover.open(&positions);
         total_proof_sizes.2 += proof_32.size();
-        total_proof_times.2 += timer.elapsed();
+        let proof_32_time = timer.elapsed();
+        total_proof_times.2 += proof_32_time;
+        samples.proof_32.push(proof_32_time.as_secs_f64() * 1000.0);
+        if artifact_hash.is_none() {
+            artifact_hash = seeded_artifact_hash(plan.input, &com, &proof_32);
+        }
 
         // Benchmark verification
         let timer = Instant::now();
@@ -270,13 +972,14 @@ where
             options.folding_factor(),
             options.remainder_max_degree(),
             com,
-        );
+        )
+        .map_err(|e| format!("{label}: {e}"))?;
         total_verify_times.0 += timer.elapsed();
 
         let timer = Instant::now();
         verifier
             .verify(&proof_1, &evaluations[0..batch_size], &positions[0..1])
-            .unwrap();
+            .map_err(|e| format!("{label}: verification of 1 position failed: {e}"))?;
         total_verify_times.1 += timer.elapsed();
 
         let timer = Instant::now();
@@ -286,111 +989,397 @@ where
                 &evaluations[0..batch_size * 16],
                 &positions[0..16],
             )
-            .unwrap();
+            .map_err(|e| format!("{label}: verification of 16 positions failed: {e}"))?;
         total_verify_times.2 += timer.elapsed();
 
         let timer = Instant::now();
         verifier
             .verify(&proof_32, &evaluations, &positions)
-            .unwrap();
-        total_verify_times.3 += timer.elapsed();
+            .map_err(|e| format!("{label}: verification of 32 positions failed: {e}"))?;
+        let verify_32_time = timer.elapsed();
+        total_verify_times.3 += verify_32_time;
+        samples
+            .verification_32
+            .push(verify_32_time.as_secs_f64() * 1000.0);
     }
 
-    FridaBenchmarkResult {
+    let timed = total_erasure_time
+        + total_commitment_time
+        + total_proof_times.0
+        + total_proof_times.1
+        + total_proof_times.2
+        + total_verify_times.0
+        + total_verify_times.1
+        + total_verify_times.2
+        + total_verify_times.3;
+    let runs = samples.proof_32.len();
+    let (log2_domain_size, extension_factor) =
+        common::domain_geometry(domain_size, encoded_data_element_count::<E>(data_size));
+    let (harness_overhead_ms, harness_overhead_pct) =
+        common::harness_overhead(config_start.elapsed(), timed, runs);
+    common::warn_on_overhead(&label, harness_overhead_pct);
+
+    Ok(FridaBenchmarkResult {
         field_type: field_name.to_string(),
+        hash: H::HASH.name(),
         batch_size,
         blowup_factor: options.blowup_factor(),
         folding_factor: options.folding_factor(),
         max_remainder_degree: options.remainder_max_degree(),
         data_size_kb: data_size / 1024,
+        footprint: DataFootprint::new::<E>(data_size, domain_size, options.blowup_factor()),
         num_queries,
-        erasure_time_ms: total_erasure_time.as_secs_f64() * 1000.0 / RUNS as f64,
-        commitment_time_ms: total_commitment_time.as_secs_f64() * 1000.0 / RUNS as f64,
-        proof_time_1_ms: total_proof_times.0.as_secs_f64() * 1000.0 / RUNS as f64,
-        proof_time_16_ms: total_proof_times.1.as_secs_f64() * 1000.0 / RUNS as f64,
-        proof_time_32_ms: total_proof_times.2.as_secs_f64() * 1000.0 / RUNS as f64,
-        verification_setup_ms: total_verify_times.0.as_secs_f64() * 1000.0 / RUNS as f64,
-        verification_1_ms: total_verify_times.1.as_secs_f64() * 1000.0 / RUNS as f64,
-        verification_16_ms: total_verify_times.2.as_secs_f64() * 1000.0 / RUNS as f64,
-        verification_32_ms: total_verify_times.3.as_secs_f64() * 1000.0 / RUNS as f64,
-        commitment_size_bytes: total_commitment_size / RUNS,
-        proof_size_1_bytes: total_proof_sizes.0 / RUNS,
-        proof_size_16_bytes: total_proof_sizes.1 / RUNS,
-        proof_size_32_bytes: total_proof_sizes.2 / RUNS,
-    }
-}
-
-pub fn run_full_benchmark(output_path: &str) {
-    let fri_options = get_standard_fri_options();
-    let data_sizes_f64 = get_standard_data_sizes::<F64Element>();
-    let data_sizes_f128 = get_standard_data_sizes::<F128Element>();
-    let num_queries_list = get_standard_num_queries();
-    let batch_sizes = get_standard_batch_sizes();
+        domain_size,
+        log2_domain_size,
+        extension_factor,
+        runs,
+        warmup_runs: plan.warmup,
+        erasure_time_ms: total_erasure_time.as_secs_f64() * 1000.0 / runs as f64,
+        erasure_spread: PhaseSpread::from_samples(&samples.erasure),
+        commitment_time_ms: total_commitment_time.as_secs_f64() * 1000.0 / runs as f64,
+        commitment_spread: PhaseSpread::from_samples(&samples.commitment),
+        proof_time_1_ms: total_proof_times.0.as_secs_f64() * 1000.0 / runs as f64,
+        proof_time_16_ms: total_proof_times.1.as_secs_f64() * 1000.0 / runs as f64,
+        proof_time_32_ms: total_proof_times.2.as_secs_f64() * 1000.0 / runs as f64,
+        proof_time_32_median_of_means_ms: stats::median_of_means(
+            &samples.proof_32,
+            MEDIAN_OF_MEANS_GROUPS,
+        ),
+        proof_time_32_ci_pct: stats::ci_relative_pct(&samples.proof_32),
+        proof_32_spread: PhaseSpread::from_samples(&samples.proof_32),
+        verification_setup_ms: total_verify_times.0.as_secs_f64() * 1000.0 / runs as f64,
+        verification_1_ms: total_verify_times.1.as_secs_f64() * 1000.0 / runs as f64,
+        verification_16_ms: total_verify_times.2.as_secs_f64() * 1000.0 / runs as f64,
+        verification_32_ms: total_verify_times.3.as_secs_f64() * 1000.0 / runs as f64,
+        verification_32_spread: PhaseSpread::from_samples(&samples.verification_32),
+        commitment_size_bytes: total_commitment_size / runs,
+        proof_size_1_bytes: total_proof_sizes.0 / runs,
+        proof_size_16_bytes: total_proof_sizes.1 / runs,
+        proof_size_32_bytes: total_proof_sizes.2 / runs,
+        pipeline: PipelineFigures::from_samples(&samples, batch_size * data_size),
+        harness_overhead_ms,
+        harness_overhead_pct,
+        low_confidence_phases: Vec::new(),
+        budget_exceeded: Vec::new(),
+        artifact_hash,
+        samples,
+        work: SweepTotals {
+            data_bytes: runs * batch_size * data_size,
+            proofs: runs * 4,
+            verifications: runs * 3,
+        },
+    })
+}
+
+/// Parameter matrix of the full Frida sweep.
+#[derive(Debug, Clone)]
+pub struct FridaSweep {
+    pub runs: usize,
+    /// Untimed runs of the whole pipeline before each configuration's measured runs.
+    pub warmup: usize,
+    pub input: InputSource,
+    pub fri_options: Vec<(usize, usize, usize)>,
+    /// Sizes in bytes of encoded field elements; the data size is derived per field type.
+    pub encoded_sizes: Vec<usize>,
+    pub num_queries: Vec<usize>,
+    pub batch_sizes: Vec<usize>,
+}
+
+impl Default for FridaSweep {
+    fn default() -> Self {
+        FridaSweep {
+            runs: RUNS,
+            warmup: 0,
+            input: InputSource::Random,
+            fri_options: get_standard_fri_options(),
+            encoded_sizes: get_standard_encoded_sizes(),
+            num_queries: get_standard_num_queries(),
+            batch_sizes: get_standard_batch_sizes(),
+        }
+    }
+}
+
+/// A named sweep for a common question, selected with `frida full --preset`. Flags given
+/// alongside it replace the corresponding part of the preset.
+pub struct SweepPreset {
+    pub name: &'static str,
+    pub description: &'static str,
+    sweep: fn() -> FridaSweep,
+}
+
+impl SweepPreset {
+    pub fn sweep(&self) -> FridaSweep {
+        (self.sweep)()
+    }
+}
+
+pub const SWEEP_PRESETS: &[SweepPreset] = &[
+    SweepPreset {
+        name: "quick",
+        description: "one small blob per folding factor, to check a machine end to end",
+        sweep: quick_sweep,
+    },
+    SweepPreset {
+        name: "rollup-small",
+        description: "128K-256K blobs in small batches, as posted by a low-traffic rollup",
+        sweep: rollup_small_sweep,
+    },
+    SweepPreset {
+        name: "rollup-large",
+        description: "1M-2M blobs in large batches, with a large remainder to keep proofs short",
+        sweep: rollup_large_sweep,
+    },
+    SweepPreset {
+        name: "high-security",
+        description: "larger blowup factors and 64-128 queries for a higher security level",
+        sweep: high_security_sweep,
+    },
+];
+
+fn quick_sweep() -> FridaSweep {
+    FridaSweep {
+        runs: 3,
+        fri_options: vec![(2, 2, 0), (2, 4, 2), (2, 8, 4), (2, 16, 8)],
+        encoded_sizes: vec![128 * 1024],
+        num_queries: vec![32],
+        batch_sizes: vec![4],
+        ..FridaSweep::default()
+    }
+}
+
+fn rollup_small_sweep() -> FridaSweep {
+    FridaSweep {
+        warmup: 2,
+        encoded_sizes: vec![128 * 1024, 256 * 1024],
+        num_queries: vec![16, 32],
+        batch_sizes: vec![2, 4],
+        ..FridaSweep::default()
+    }
+}
+
+fn rollup_large_sweep() -> FridaSweep {
+    FridaSweep {
+        runs: 5,
+        warmup: 1,
+        fri_options: vec![(2, 4, 256), (2, 8, 256), (2, 16, 256)],
+        encoded_sizes: vec![1024 * 1024, 2048 * 1024],
+        num_queries: vec![32],
+        batch_sizes: vec![8, 16],
+        ..FridaSweep::default()
+    }
+}
+
+fn high_security_sweep() -> FridaSweep {
+    FridaSweep {
+        warmup: 2,
+        fri_options: vec![(4, 4, 2), (4, 8, 4), (8, 4, 2), (8, 8, 4)],
+        encoded_sizes: vec![256 * 1024, 1024 * 1024],
+        num_queries: vec![64, 128],
+        batch_sizes: vec![4],
+        ..FridaSweep::default()
+    }
+}
+
+/// Parses a preset name for `--preset`.
+pub fn parse_sweep_preset(value: &str) -> Result<&'static SweepPreset, String> {
+    SWEEP_PRESETS
+        .iter()
+        .find(|p| p.name == value)
+        .ok_or_else(|| {
+            let names = SWEEP_PRESETS.iter().map(|p| p.name).collect::<Vec<_>>();
+            format!(
+                "unknown preset '{value}', expected one of {}",
+                names.join(", ")
+            )
+        })
+}
+
+/// Prints every preset with the matrix it expands to.
+pub fn print_presets() {
+    for preset in SWEEP_PRESETS {
+        let sweep = preset.sweep();
+        let fri_options = sweep
+            .fri_options
+            .iter()
+            .map(|(b, f, r)| format!("{b}:{f}:{r}"))
+            .collect::<Vec<_>>();
+        let sizes = sweep
+            .encoded_sizes
+            .iter()
+            .map(|size| format!("{}K", size / 1024))
+            .collect::<Vec<_>>();
+        println!("{}: {}", preset.name, preset.description);
+        println!(
+            "  --runs {} --warmup {} --fri-options {} --data-sizes {} --num-queries {} --batch-sizes {}",
+            sweep.runs,
+            sweep.warmup,
+            fri_options.join(","),
+            sizes.join(","),
+            comma_separated(&sweep.num_queries),
+            comma_separated(&sweep.batch_sizes)
+        );
+    }
+}
+
+fn comma_separated(values: &[usize]) -> String {
+    values
+        .iter()
+        .map(usize::to_string)
+        .collect::<Vec<_>>()
+        .join(",")
+}
+
+/// Runs the sweep. Returns false if any run exceeded one of `budgets`.
+///
+/// The CSV at `output_path` is written once the sweep is done; if `json_output` is set, each
+/// result is also appended there as soon as its configuration finishes.
+pub fn run_full_benchmark(
+    output_path: &str,
+    json_output: Option<&str>,
+    scaling_output: Option<&str>,
+    sweep: &FridaSweep,
+    budgets: &[PhaseBudget],
+) -> bool {
+    let fri_options = &sweep.fri_options;
+    let data_sizes_f64 = sweep
+        .encoded_sizes
+        .iter()
+        .map(|&size| data_size_for_encoded_size::<F64Element>(size))
+        .collect::<Vec<_>>();
+    let data_sizes_f128 = sweep
+        .encoded_sizes
+        .iter()
+        .map(|&size| data_size_for_encoded_size::<F128Element>(size))
+        .collect::<Vec<_>>();
+    let num_queries_list = &sweep.num_queries;
+    let batch_sizes = &sweep.batch_sizes;
+    let plan = RunPlan {
+        policy: RunPolicy::Fixed(sweep.runs),
+        warmup: sweep.warmup,
+        input: sweep.input,
+    };
 
     let mut results = Vec::new();
+    let mut json_writer = json_output
+        .map(|path| common::JsonLinesWriter::create(path).expect("Failed to create JSON output"));
 
     println!("Running full Frida benchmark suite...");
+    let sweep_start = Instant::now();
+    let timer_overhead = calibrate_timer_overhead();
+    // Configurations that fail or panic are skipped; a panic has already been printed by the hook
+    let mut record = |outcome: std::thread::Result<Result<FridaBenchmarkResult, String>>| {
+        let mut result = match outcome {
+            Ok(Ok(result)) => result,
+            Ok(Err(e)) => {
+                eprintln!("Skipping {e}");
+                return;
+            }
+            Err(_) => return,
+        };
+        result.mark_low_confidence(timer_overhead);
+        result.check_budgets(budgets);
+        if let Some(writer) = json_writer.as_mut() {
+            writer
+                .append(&result)
+                .expect("Failed to append JSON result");
+        }
+        results.push(result);
+    };
     println!("Configurations: {} FRI options × {} data sizes × {} queries × {} batch sizes × 2 field types",
         fri_options.len(), data_sizes_f64.len(), num_queries_list.len(), batch_sizes.len());
+    let mut oversized = 0;
+    for &(blowup_factor, _, _) in fri_options {
+        for (&data_size_f64, &data_size_f128) in data_sizes_f64.iter().zip(data_sizes_f128.iter()) {
+            for batch_size in std::iter::once(1).chain(batch_sizes.iter().copied()) {
+                oversized += [
+                    common::check_domain_fits::<F64Element>(
+                        data_size_f64,
+                        batch_size,
+                        blowup_factor,
+                    ),
+                    common::check_domain_fits::<F128Element>(
+                        data_size_f128,
+                        batch_size,
+                        blowup_factor,
+                    ),
+                ]
+                .iter()
+                .filter(|fits| fits.is_err())
+                .count();
+            }
+        }
+    }
+    if oversized > 0 {
+        println!(
+            "Warning: {} configurations need a domain above the maximum of 2^{} and will be skipped",
+            oversized * num_queries_list.len(),
+            constants::MAX_DOMAIN_SIZE.ilog2()
+        );
+    }
 
-    for &(blowup_factor, folding_factor, max_remainder_degree) in &fri_options {
+    for &(blowup_factor, folding_factor, max_remainder_degree) in fri_options {
         let options = FriOptions::new(blowup_factor, folding_factor, max_remainder_degree);
 
         for (&data_size_f64, &data_size_f128) in data_sizes_f64.iter().zip(data_sizes_f128.iter()) {
-            for &num_queries in &num_queries_list {
+            for &num_queries in num_queries_list {
                 // Non-batched (batch_size = 1)
-                if let Ok(result) = std::panic::catch_unwind(|| {
+                record(std::panic::catch_unwind(|| {
                     benchmark_non_batched::<F64Element, Blake3F64>(
                         options.clone(),
                         data_size_f64,
                         num_queries,
+                        plan,
                         field_names::F64,
                     )
-                }) {
-                    results.push(result);
-                }
+                }));
 
-                if let Ok(result) = std::panic::catch_unwind(|| {
+                record(std::panic::catch_unwind(|| {
                     benchmark_non_batched::<F128Element, Blake3F128>(
                         options.clone(),
                         data_size_f128,
                         num_queries,
+                        plan,
                         field_names::F128,
                     )
-                }) {
-                    results.push(result);
-                }
+                }));
 
                 // Batched
-                for &batch_size in &batch_sizes {
-                    if let Ok(result) = std::panic::catch_unwind(|| {
+                for &batch_size in batch_sizes {
+                    record(std::panic::catch_unwind(|| {
                         benchmark_batched::<F64Element, Blake3F64>(
                             options.clone(),
                             data_size_f64,
                             batch_size,
                             num_queries,
+                            plan,
                             field_names::F64,
                         )
-                    }) {
-                        results.push(result);
-                    }
+                    }));
 
-                    if let Ok(result) = std::panic::catch_unwind(|| {
+                    record(std::panic::catch_unwind(|| {
                         benchmark_batched::<F128Element, Blake3F128>(
                             options.clone(),
                             data_size_f128,
                             batch_size,
                             num_queries,
+                            plan,
                             field_names::F128,
                         )
-                    }) {
-                        results.push(result);
-                    }
+                    }));
                 }
             }
         }
     }
 
+    common::print_low_confidence_summary(
+        results
+            .iter()
+            .filter(|r| !r.low_confidence_phases.is_empty())
+            .count(),
+        timer_overhead,
+    );
+    let within_budget = apply_budgets(&mut results, budgets);
+
     common::save_results_with_header(
         &results,
         output_path,
@@ -398,77 +1387,446 @@ pub fn run_full_benchmark(output_path: &str) {
         |r| r.to_csv(),
     )
     .expect("Failed to save results");
+    common::print_overhead_summary(
+        &results
+            .iter()
+            .map(|r| r.harness_overhead_pct)
+            .collect::<Vec<_>>(),
+    );
     println!(
         "Frida benchmark completed with {} successful results",
         results.len()
     );
+    let totals = results
+        .iter()
+        .fold(SweepTotals::default(), |acc, r| acc + r.work);
+    println!("{}", totals.summary(sweep_start.elapsed()));
+
+    let fits = fit_scaling(&results);
+    print_scaling(&fits);
+    if let Some(path) = scaling_output {
+        let mut writer =
+            common::JsonLinesWriter::create(path).expect("Failed to create scaling output");
+        for fit in &fits {
+            writer.append(fit).expect("Failed to append scaling fit");
+        }
+        println!("Scaling fits written to {path}");
+    }
+    within_budget
 }
 
-pub fn run_custom_benchmark(
-    blowup_factor: usize,
-    folding_factor: usize,
-    max_remainder_degree: usize,
+pub struct CustomFridaBenchmarkConfig<'a> {
+    pub blowup_factor: usize,
+    pub folding_factor: usize,
+    pub max_remainder_degree: usize,
+    pub data_size: usize,
+    pub batch_size: usize,
+    pub num_queries: usize,
+    pub hash: HashFunction,
+    pub run_policy: RunPolicy,
+    pub warmup: usize,
+    pub input: InputSource,
+    pub budgets: &'a [PhaseBudget],
+    pub output_path: &'a str,
+    pub profile_output: Option<&'a str>,
+}
+
+/// Prints hashes of the first run's input and of the commitment to it, so that runs on different
+/// machines or code versions can be checked to have committed to the same data, along with the
+/// positions the first run opens.
+fn print_fingerprint<E, H>(
+    options: &FriOptions,
+    input: InputSource,
     data_size: usize,
     batch_size: usize,
     num_queries: usize,
-    output_path: &str,
-) {
-    let options = FriOptions::new(blowup_factor, folding_factor, max_remainder_degree);
-    let mut results = Vec::new();
+    field_name: &str,
+) -> Result<(), String>
+where
+    E: FieldElement,
+    H: ElementHasher<BaseField = E::BaseField>,
+{
+    let data_list = (0..batch_size)
+        .map(|index| input.blob(index, data_size))
+        .collect::<Vec<_>>();
+    let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
+    let (com, prover) = if batch_size > 1 {
+        prover_builder.commit_and_prove_batch(&data_list, num_queries)
+    } else {
+        prover_builder.commit_and_prove(&data_list[0], num_queries)
+    }
+    .map_err(|e| format!("{field_name}: commitment failed: {e}"))?;
+    // The prover records these for the timed runs only
+    unsafe {
+        ERASURE_TIME = None;
+        COMMIT_TIME = None;
+    }
 
-    println!("Running custom Frida benchmark...");
     println!(
-        "Parameters: blowup={}, folding={}, remainder={}, data={}KB, batch={}, queries={}",
-        blowup_factor,
-        folding_factor,
-        max_remainder_degree,
-        data_size / 1024,
-        batch_size,
-        num_queries
+        "  {field_name}: input {}, commitment {}",
+        hex_digest::<H>(&data_list.concat()),
+        hex_digest::<H>(&com.to_bytes())
     );
+    let positions = draw_positions(input, 0, &com);
+    let proof_32 = prover.open(&positions);
+    println!(
+        "  {field_name}: blob id {}, positions opened {}",
+        sampler::blob_id(&com.to_bytes())
+            .map(|b| format!("{b:02x}"))
+            .concat(),
+        positions
+            .iter()
+            .map(usize::to_string)
+            .collect::<Vec<_>>()
+            .join(",")
+    );
+    if let Some(hash) = seeded_artifact_hash(input, &com, &proof_32) {
+        println!("  {field_name}: artifacts {hash}");
+    }
+    Ok(())
+}
+
+/// Prints the fingerprints of a custom configuration, if seeded, and runs it for both field types
+/// with the given hashers.
+fn run_custom_fields<H64, H128>(
+    options: &FriOptions,
+    config: &CustomFridaBenchmarkConfig,
+    plan: RunPlan,
+) -> Result<Vec<FridaBenchmarkResult>, String>
+where
+    H64: ElementHasher<BaseField = F64Element> + NamedHasher,
+    H128: ElementHasher<BaseField = F128Element> + NamedHasher,
+{
+    let mut results = Vec::new();
+    if let InputSource::Seeded(seed) = config.input {
+        println!(
+            "Input seeded with {seed}, first run fingerprints ({}):",
+            config.hash.name()
+        );
+        print_fingerprint::<F64Element, H64>(
+            options,
+            config.input,
+            config.data_size,
+            config.batch_size,
+            config.num_queries,
+            field_names::F64,
+        )?;
+        print_fingerprint::<F128Element, H128>(
+            options,
+            config.input,
+            config.data_size,
+            config.batch_size,
+            config.num_queries,
+            field_names::F128,
+        )?;
+    }
 
-    if batch_size > 1 {
-        let result_f64 = benchmark_batched::<F64Element, Blake3F64>(
+    if config.batch_size > 1 {
+        let result_f64 = benchmark_batched::<F64Element, H64>(
             options.clone(),
-            data_size,
-            batch_size,
-            num_queries,
+            config.data_size,
+            config.batch_size,
+            config.num_queries,
+            plan,
             field_names::F64,
-        );
+        )?;
         results.push(result_f64);
 
-        let result_f128 = benchmark_batched::<F128Element, Blake3F128>(
+        let result_f128 = benchmark_batched::<F128Element, H128>(
             options.clone(),
-            data_size,
-            batch_size,
-            num_queries,
+            config.data_size,
+            config.batch_size,
+            config.num_queries,
+            plan,
             field_names::F128,
-        );
+        )?;
         results.push(result_f128);
     } else {
-        let result_f64 = benchmark_non_batched::<F64Element, Blake3F64>(
+        let result_f64 = benchmark_non_batched::<F64Element, H64>(
             options.clone(),
-            data_size,
-            num_queries,
+            config.data_size,
+            config.num_queries,
+            plan,
             field_names::F64,
-        );
+        )?;
         results.push(result_f64);
 
-        let result_f128 = benchmark_non_batched::<F128Element, Blake3F128>(
+        let result_f128 = benchmark_non_batched::<F128Element, H128>(
             options.clone(),
-            data_size,
-            num_queries,
+            config.data_size,
+            config.num_queries,
+            plan,
             field_names::F128,
-        );
+        )?;
         results.push(result_f128);
     }
 
+    Ok(results)
+}
+
+/// Runs a single configuration. Returns `Ok(false)` if any run exceeded one of `config.budgets`.
+pub fn run_custom_benchmark(config: CustomFridaBenchmarkConfig) -> Result<bool, String> {
+    let options = FriOptions::new(
+        config.blowup_factor,
+        config.folding_factor,
+        config.max_remainder_degree,
+    );
+
+    println!("Running custom Frida benchmark...");
+    println!(
+        "Parameters: blowup={}, folding={}, remainder={}, data={}KB, batch={}, queries={}, hash={}",
+        config.blowup_factor,
+        config.folding_factor,
+        config.max_remainder_degree,
+        config.data_size / 1024,
+        config.batch_size,
+        config.num_queries,
+        config.hash.name()
+    );
+    let timer_overhead = calibrate_timer_overhead();
+    if let RunPolicy::Adaptive { ci_pct, max_runs } = config.run_policy {
+        println!("Adaptive runs: target CI {ci_pct}% of mean, at most {max_runs} runs");
+    }
+    let plan = RunPlan {
+        policy: config.run_policy,
+        warmup: config.warmup,
+        input: config.input,
+    };
+    let mut results = match config.hash {
+        HashFunction::Blake3 => {
+            run_custom_fields::<Blake3F64, Blake3F128>(&options, &config, plan)?
+        }
+        HashFunction::Sha3 => run_custom_fields::<Sha3F64, Sha3F128>(&options, &config, plan)?,
+    };
+
+    for result in &mut results {
+        result.mark_low_confidence(timer_overhead);
+    }
+    common::print_low_confidence_summary(
+        results
+            .iter()
+            .filter(|r| !r.low_confidence_phases.is_empty())
+            .count(),
+        timer_overhead,
+    );
+    let within_budget = apply_budgets(&mut results, config.budgets);
+
     common::save_results_with_header(
         &results,
-        output_path,
+        config.output_path,
         &FridaBenchmarkResult::csv_header(),
         |r| r.to_csv(),
     )
     .expect("Failed to save results");
     println!("Custom Frida benchmark completed successfully");
+
+    if let Some(profile_output) = config.profile_output {
+        write_profile(&results, profile_output).expect("Failed to save profile");
+        println!("Per-run profile written to {profile_output}");
+        print_warmup_recommendation(&results);
+    }
+
+    for result in &results {
+        println!(
+            "  {}: {} runs, proof_32 mean {:.3} ms{}, median-of-means {:.3} ms, CI ±{:.1}%, stddev {:.3} ms, min/p50/p90/p99/max {:.3}/{:.3}/{:.3}/{:.3}/{:.3} ms",
+            result.field_type,
+            result.runs,
+            result.proof_time_32_ms,
+            if result.low_confidence_phases.contains(&"proof_32") {
+                "*"
+            } else {
+                ""
+            },
+            result.proof_time_32_median_of_means_ms,
+            result.proof_time_32_ci_pct,
+            result.proof_32_spread.stddev_ms,
+            result.proof_32_spread.min_ms,
+            result.proof_32_spread.p50_ms,
+            result.proof_32_spread.p90_ms,
+            result.proof_32_spread.p99_ms,
+            result.proof_32_spread.max_ms
+        );
+        println!(
+            "  {}: {} bytes of data, {} encoded, {} padded ({:.1}% overhead)",
+            result.field_type,
+            result.footprint.data_size_bytes,
+            result.footprint.encoded_size_bytes,
+            result.footprint.padded_size_bytes,
+            result.footprint.padding_overhead_pct
+        );
+        println!(
+            "  {}: {} systematic, {} padding and {} parity evaluations",
+            result.field_type,
+            result.footprint.systematic_evaluations,
+            result.footprint.padding_evaluations,
+            result.footprint.parity_evaluations
+        );
+        println!(
+            "  {}: pipeline mean {:.3} ms, p50/p99 {:.3}/{:.3} ms, prover {:.1} MB/s, verifier {:.0} samples/s",
+            result.field_type,
+            result.pipeline.pipeline_latency_ms,
+            result.pipeline.pipeline_latency_p50_ms,
+            result.pipeline.pipeline_latency_p99_ms,
+            result.pipeline.prover_throughput_mb_s,
+            result.pipeline.verifier_samples_per_s
+        );
+    }
+    Ok(within_budget)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_presets_pass_flag_validation() {
+        // A preset must be something the equivalent flags would have accepted
+        for preset in SWEEP_PRESETS {
+            let sweep = preset.sweep();
+            assert!(common::parse_run_count(&sweep.runs.to_string()).is_ok());
+            for &(b, f, r) in &sweep.fri_options {
+                assert_eq!(
+                    common::parse_fri_option(&format!("{b}:{f}:{r}")),
+                    Ok((b, f, r)),
+                    "{}",
+                    preset.name
+                );
+            }
+            for &size in &sweep.encoded_sizes {
+                assert!(common::parse_encoded_size(&size.to_string()).is_ok());
+            }
+            for &queries in &sweep.num_queries {
+                assert!(common::parse_run_count(&queries.to_string()).is_ok());
+            }
+            for &batch_size in &sweep.batch_sizes {
+                assert!(common::parse_sweep_batch_size(&batch_size.to_string()).is_ok());
+            }
+            assert!(!sweep.fri_options.is_empty() && !sweep.encoded_sizes.is_empty());
+            assert!(!sweep.num_queries.is_empty() && !sweep.batch_sizes.is_empty());
+            assert!(matches!(parse_sweep_preset(preset.name), Ok(p) if p.name == preset.name));
+        }
+        assert!(parse_sweep_preset("gaming").is_err());
+    }
+
+    #[test]
+    fn test_scaling_is_fitted_per_configuration() {
+        let _prover = common::lock_prover();
+        let options = FriOptions::new(2, 2, 0);
+        let plan = RunPlan {
+            policy: RunPolicy::Fixed(2),
+            warmup: 0,
+            input: InputSource::Seeded(3),
+        };
+        let results = [1024, 2048, 4096]
+            .into_iter()
+            .map(|data_size| {
+                benchmark_non_batched::<F64Element, Blake3F64>(
+                    options.clone(),
+                    data_size,
+                    8,
+                    plan,
+                    field_names::F64,
+                )
+                .unwrap()
+            })
+            .collect::<Vec<_>>();
+
+        let fits = fit_scaling(&results);
+        assert_eq!(
+            fits.iter().map(|f| f.phase).collect::<Vec<_>>(),
+            BUDGET_PHASES
+        );
+        for fit in &fits {
+            assert_eq!(fit.data_sizes, 3);
+            assert!(fit.exponent.is_finite(), "{fit:?}");
+            assert!((0.0..=1.0 + 1e-9).contains(&fit.r_squared), "{fit:?}");
+        }
+        // Two sizes are not enough to tell a trend from noise
+        assert!(fit_scaling(&results[..2]).is_empty());
+    }
+
+    #[test]
+    fn test_pipeline_figures_are_per_run() {
+        let samples = PhaseSamples {
+            erasure: vec![1.0, 3.0],
+            commitment: vec![1.0, 3.0],
+            proof_32: vec![2.0, 2.0],
+            verification_32: vec![0.0, 4.0],
+            positions: Vec::new(),
+        };
+        let figures = PipelineFigures::from_samples(&samples, 1024 * 1024);
+        assert_eq!(figures.pipeline_latency_ms, 6.0);
+        assert_eq!(figures.pipeline_latency_p50_ms, 4.0);
+        assert_eq!(figures.pipeline_latency_p99_ms, 8.0);
+        // Runs of 4 and 8 ms give 250 and 125 MB/s; the ratio of the averages would be 166.7
+        assert_eq!(figures.prover_throughput_mb_s, 125.0);
+        // The run below timer resolution is left out
+        assert_eq!(figures.verifier_samples_per_s, 8000.0);
+    }
+
+    #[test]
+    fn test_benchmark_returns_result() {
+        let _prover = common::lock_prover();
+        let options = FriOptions::new(2, 2, 0);
+        let plan = RunPlan {
+            policy: RunPolicy::Fixed(2),
+            warmup: 1,
+            input: InputSource::Seeded(7),
+        };
+        let result = benchmark_batched::<F64Element, Blake3F64>(
+            options.clone(),
+            1024,
+            2,
+            8,
+            plan,
+            field_names::F64,
+        )
+        .unwrap();
+        assert_eq!(result.runs, 2);
+        assert_eq!(result.warmup_runs, 1);
+        assert_eq!(result.samples.get("proof_32").len(), 2);
+        assert_eq!(result.samples.positions.len(), 2);
+        assert!(result.samples.positions.iter().all(|p| p.len() == 32));
+        assert!(result.pipeline.pipeline_latency_ms >= result.proof_time_32_ms);
+        assert!(result.pipeline.prover_throughput_mb_s > 0.0);
+        assert_eq!(
+            result.footprint,
+            DataFootprint::new::<F64Element>(1024, result.domain_size, 2)
+        );
+        // Seeded, so the same configuration gives the same artifacts, and random input none
+        let again = benchmark_batched::<F64Element, Blake3F64>(
+            options.clone(),
+            1024,
+            2,
+            8,
+            plan,
+            field_names::F64,
+        )
+        .unwrap();
+        assert!(result.artifact_hash.is_some());
+        assert_eq!(again.artifact_hash, result.artifact_hash);
+        let random = RunPlan {
+            input: InputSource::Random,
+            ..plan
+        };
+        let result = benchmark_batched::<F64Element, Blake3F64>(
+            options.clone(),
+            1024,
+            2,
+            8,
+            random,
+            field_names::F64,
+        )
+        .unwrap();
+        assert_eq!(result.artifact_hash, None);
+
+        let plan = RunPlan { warmup: 0, ..plan };
+        let err =
+            benchmark_non_batched::<F64Element, Blake3F64>(options, 0, 8, plan, field_names::F64)
+                .unwrap_err();
+        assert!(
+            err.starts_with("f64 fri=(2,2,0) batch=1 data=0KB queries=8: commitment failed"),
+            "{err}"
+        );
+    }
 }
@@ -1,18 +1,53 @@
 #![cfg(feature = "bench")]
 
-use clap::{Parser, Subcommand};
+use clap::{error::ErrorKind, Parser, Subcommand};
 
 mod common;
+mod compare;
 mod defrida;
+mod determinism;
+mod fragmentation;
 mod frida;
+mod memory;
+mod positions;
+mod preflight;
+mod recovery;
+mod sampler;
 mod single_frida;
+mod stats;
+
+// Installed in every build so that `--track-memory` needs no separate binary. Outside of its
+// untimed pass each allocation and free costs one relaxed load of a flag that never changes
+// during the timed runs, which is small next to the allocation itself and the same for every
+// configuration being compared.
+#[global_allocator]
+static GLOBAL: memory::CountingAllocator = memory::CountingAllocator;
+
+/// Exit code for command line usage errors (EX_USAGE from sysexits.h).
+const EXIT_USAGE: i32 = 64;
+
+const EXAMPLES: &str = "\
+Examples:
+  Quick run of one configuration:
+    frida-bench frida custom --blowup-factor 2 --folding-factor 2 --max-remainder-degree 0 --data-size 65536
+
+  Full sweep over the standard configurations:
+    frida-bench frida full --output bench/results/frida_full.csv
+
+  Distributed proving across 16 validators:
+    frida-bench defrida custom --blowup-factor 2 --folding-factor 4 --max-remainder-degree 2 \\
+      --data-size 131072 --num-validators 16 --num-queries 32";
 
 #[derive(Parser)]
 #[command(name = "frida-bench")]
 #[command(about = "Comprehensive benchmark suite for FRI implementations")]
+#[command(after_help = EXAMPLES)]
 struct Cli {
     #[command(subcommand)]
     command: Commands,
+    /// Free-form label recorded with the machine metadata at the top of every result file
+    #[arg(long, global = true, value_name = "TEXT")]
+    label: Option<String>,
 }
 
 #[derive(Subcommand)]
@@ -32,6 +67,19 @@ enum Commands {
         #[command(subcommand)]
         subcommand: DefridaSubcommand,
     },
+    /// Compare two per-run profiles (from `frida custom --profile-runs`) phase by phase
+    Compare {
+        /// Profile of the baseline
+        old: String,
+        /// Profile to compare against the baseline
+        new: String,
+        /// Significance level of the per-phase Mann-Whitney U tests
+        #[arg(long, default_value = "0.05", value_parser = compare::parse_alpha)]
+        alpha: f64,
+        /// Also write each comparison as a JSON line to this file
+        #[arg(long, value_name = "PATH")]
+        json_output: Option<String>,
+    },
 }
 
 #[derive(Subcommand)]
@@ -39,22 +87,147 @@ enum BenchmarkSubcommand {
     Full {
         #[arg(long, default_value = "bench/results/frida_full.csv")]
         output: String,
+        /// Also write each result as a JSON line to this file as soon as it is finished
+        #[arg(long, value_name = "PATH")]
+        json_output: Option<String>,
+        /// Write how each phase's time scales with the data size, per configuration, as JSON lines
+        #[arg(long, value_name = "PATH")]
+        scaling_output: Option<String>,
+        /// Start from a named sweep (see `frida presets`); sweep flags given as well override it
+        #[arg(long, value_name = "NAME", value_parser = frida::parse_sweep_preset, help_heading = "Sweep")]
+        preset: Option<&'static frida::SweepPreset>,
+        /// Runs per configuration [default: 10]
+        #[arg(long, value_parser = common::parse_run_count, help_heading = "Sweep")]
+        runs: Option<usize>,
+        /// Untimed runs before each configuration's measured runs [default: 0]
+        #[arg(long, value_name = "N", help_heading = "Sweep")]
+        warmup: Option<usize>,
+        /// Derive every input blob from this seed instead of fresh random bytes
+        #[arg(long, value_name = "N", help_heading = "Sweep")]
+        seed: Option<u64>,
+        /// FRI options to sweep as blowup:folding:max_remainder_degree [default: standard set]
+        #[arg(long, value_delimiter = ',', value_name = "B:F:R", value_parser = common::parse_fri_option, help_heading = "Sweep")]
+        fri_options: Vec<(usize, usize, usize)>,
+        /// Encoded sizes to sweep, with optional K/M suffix [default: 128K,256K,512K,1M,2M]
+        #[arg(long, value_delimiter = ',', value_name = "SIZE", value_parser = common::parse_encoded_size, help_heading = "Sweep")]
+        data_sizes: Vec<usize>,
+        /// Query counts to sweep [default: 8,16,32]
+        #[arg(long, value_delimiter = ',', value_name = "N", value_parser = common::parse_run_count, help_heading = "Sweep")]
+        num_queries: Vec<usize>,
+        /// Batch sizes for the batched configurations [default: 2,4,8,16]
+        #[arg(long, value_delimiter = ',', value_name = "N", value_parser = common::parse_sweep_batch_size, help_heading = "Sweep")]
+        batch_sizes: Vec<usize>,
+        /// Skip the quick end-to-end check that runs before the sweep
+        #[arg(long)]
+        skip_preflight: bool,
+        /// Fail if any single run of a phase exceeds a budget, e.g. `proof_32<=3s` (repeatable)
+        #[arg(long = "budget-check", value_name = "PHASE<=DURATION", value_parser = frida::parse_phase_budget)]
+        budgets: Vec<frida::PhaseBudget>,
     },
+    /// List the named sweeps accepted by `full --preset`
+    Presets,
     Custom {
-        #[arg(long)]
+        #[arg(long, help_heading = "FRI options")]
         blowup_factor: usize,
-        #[arg(long)]
+        #[arg(long, help_heading = "FRI options")]
         folding_factor: usize,
-        #[arg(long)]
+        #[arg(long, help_heading = "FRI options")]
         max_remainder_degree: usize,
-        #[arg(long)]
+        #[arg(long, value_parser = common::parse_data_size)]
         data_size: usize,
         #[arg(long, default_value = "1")]
         batch_size: usize,
         #[arg(long, default_value = "32")]
         num_queries: usize,
+        /// Hash for the Merkle trees and the Fiat-Shamir channel: blake3 or sha3
+        #[arg(long, default_value = "blake3", value_parser = common::parse_hash_function)]
+        hash: common::HashFunction,
         #[arg(long, default_value = "bench/results/frida_custom.csv")]
         output: String,
+        #[arg(long)]
+        determinism_check: bool,
+        /// Before benchmarking, check that corrupted evaluations, proofs and commitments fail
+        /// verification with these parameters
+        #[arg(long)]
+        sanity: bool,
+        /// Before benchmarking, open and verify each of these positions on its own, e.g. 0,17,4095
+        #[arg(long, value_name = "POSITIONS", value_delimiter = ',')]
+        verify_positions: Vec<usize>,
+        #[arg(long)]
+        adaptive_runs: bool,
+        #[arg(long, default_value = "5", requires = "adaptive_runs", value_parser = common::parse_ci_pct)]
+        ci: f64,
+        #[arg(long, default_value = "100", requires = "adaptive_runs", value_parser = common::parse_max_runs)]
+        max_runs: usize,
+        /// Untimed runs before the measured runs
+        #[arg(long, value_name = "N", default_value = "0")]
+        warmup: usize,
+        /// Derive every input blob from this seed and print fingerprints of the first run
+        #[arg(long, value_name = "N")]
+        seed: Option<u64>,
+        /// Before benchmarking, run once untimed and record heap allocations per phase
+        #[arg(long)]
+        track_memory: bool,
+        #[arg(
+            long,
+            default_value = "bench/results/frida_memory.csv",
+            requires = "track_memory"
+        )]
+        memory_output: String,
+        /// Run exactly N times and write every run to --profile-output to find warm-up effects
+        #[arg(
+            long,
+            value_name = "N",
+            value_parser = common::parse_run_count,
+            conflicts_with = "adaptive_runs"
+        )]
+        profile_runs: Option<usize>,
+        #[arg(
+            long,
+            default_value = "bench/results/frida_profile.csv",
+            requires = "profile_runs"
+        )]
+        profile_output: String,
+        /// Fail if any single run of a phase exceeds a budget, e.g. `proof_32<=3s` (repeatable)
+        #[arg(long = "budget-check", value_name = "PHASE<=DURATION", value_parser = frida::parse_phase_budget)]
+        budgets: Vec<frida::PhaseBudget>,
+    },
+    /// Verify K single-position openings one call at a time vs one K-position opening
+    Fragmentation {
+        #[arg(long, help_heading = "FRI options")]
+        blowup_factor: usize,
+        #[arg(long, help_heading = "FRI options")]
+        folding_factor: usize,
+        #[arg(long, help_heading = "FRI options")]
+        max_remainder_degree: usize,
+        #[arg(long, value_parser = common::parse_data_size)]
+        data_size: usize,
+        #[arg(long, default_value = "1")]
+        batch_size: usize,
+        /// Values of K to sweep
+        #[arg(long, value_delimiter = ',', default_value = "1,2,4,8,16,32")]
+        position_counts: Vec<usize>,
+        #[arg(long, default_value = "bench/results/frida_fragmentation.csv")]
+        output: String,
+    },
+    /// Rebuild data from the minimal set of evaluations, verifying first vs recommitting after
+    Recovery {
+        #[arg(long, help_heading = "FRI options")]
+        blowup_factor: usize,
+        #[arg(long, help_heading = "FRI options")]
+        folding_factor: usize,
+        #[arg(long, help_heading = "FRI options")]
+        max_remainder_degree: usize,
+        /// Data sizes in bytes; decoding is quadratic in the domain size, so keep these small
+        #[arg(
+            long,
+            value_delimiter = ',',
+            default_value = "1024,4096,16384",
+            value_parser = common::parse_data_size
+        )]
+        data_sizes: Vec<usize>,
+        #[arg(long, default_value = "bench/results/frida_recovery.csv")]
+        output: String,
     },
 }
 
@@ -63,15 +236,18 @@ enum SingleFridaSubcommand {
     Full {
         #[arg(long, default_value = "bench/results/single_frida_full.csv")]
         output: String,
+        /// Skip the quick end-to-end check that runs before the sweep
+        #[arg(long)]
+        skip_preflight: bool,
     },
     Custom {
-        #[arg(long)]
+        #[arg(long, help_heading = "FRI options")]
         blowup_factor: usize,
-        #[arg(long)]
+        #[arg(long, help_heading = "FRI options")]
         folding_factor: usize,
-        #[arg(long)]
+        #[arg(long, help_heading = "FRI options")]
         max_remainder_degree: usize,
-        #[arg(long)]
+        #[arg(long, value_parser = common::parse_data_size)]
         data_size: usize,
         #[arg(long, default_value = "1")]
         batch_size: usize,
@@ -85,17 +261,20 @@ enum DefridaSubcommand {
     Full {
         #[arg(long, default_value = "bench/results/defrida_full.csv")]
         output: String,
+        /// Skip the quick end-to-end check that runs before the sweep
+        #[arg(long)]
+        skip_preflight: bool,
     },
     Custom {
-        #[arg(long)]
+        #[arg(long, help_heading = "FRI options")]
         blowup_factor: usize,
-        #[arg(long)]
+        #[arg(long, help_heading = "FRI options")]
         folding_factor: usize,
-        #[arg(long)]
+        #[arg(long, help_heading = "FRI options")]
         max_remainder_degree: usize,
-        #[arg(long)]
+        #[arg(long, value_parser = common::parse_data_size)]
         data_size: usize,
-        #[arg(long)]
+        #[arg(long, value_parser = common::parse_validator_count)]
         num_validators: usize,
         #[arg(long)]
         num_queries: usize,
@@ -103,17 +282,105 @@ enum DefridaSubcommand {
         batch_size: usize,
         #[arg(long, default_value = "bench/results/defrida_custom.csv")]
         output: String,
+        /// Also write a per-validator CSV for each field type into this directory, verifying
+        /// every validator's proof
+        #[arg(long, value_name = "DIR")]
+        validator_breakdown: Option<String>,
     },
 }
 
+fn parse_cli() -> Cli {
+    Cli::try_parse().unwrap_or_else(|e| {
+        // Help and version requests are not usage errors
+        if matches!(e.kind(), ErrorKind::DisplayHelp | ErrorKind::DisplayVersion) {
+            e.exit();
+        }
+        let _ = e.print();
+        std::process::exit(EXIT_USAGE);
+    })
+}
+
+/// Runs the preflight check before a full sweep, exiting if it fails.
+fn run_preflight(output: &str, skip: bool) {
+    if skip {
+        println!("Skipping preflight");
+        return;
+    }
+    match preflight::run_preflight(output) {
+        Ok(report) => report.print("Preflight"),
+        Err(e) => {
+            eprintln!("Preflight failed, not starting the sweep: {e}");
+            std::process::exit(1);
+        }
+    }
+}
+
 fn main() {
-    let cli = Cli::parse();
+    let cli = parse_cli();
+    common::RunMetadata::collect(cli.label.clone()).record();
 
     match cli.command {
         Commands::Frida { subcommand } => match subcommand {
-            BenchmarkSubcommand::Full { output } => {
-                frida::run_full_benchmark(&output);
+            BenchmarkSubcommand::Full {
+                output,
+                preset,
+                runs,
+                warmup,
+                seed,
+                fri_options,
+                data_sizes,
+                num_queries,
+                batch_sizes,
+                budgets,
+                skip_preflight,
+                json_output,
+                scaling_output,
+            } => {
+                let mut sweep = preset.map_or_else(frida::FridaSweep::default, |p| p.sweep());
+                let mut overridden = Vec::new();
+                if let Some(runs) = runs {
+                    sweep.runs = runs;
+                    overridden.push("--runs");
+                }
+                if let Some(warmup) = warmup {
+                    sweep.warmup = warmup;
+                    overridden.push("--warmup");
+                }
+                sweep.input = seed.map_or(common::InputSource::Random, common::InputSource::Seeded);
+                if !fri_options.is_empty() {
+                    sweep.fri_options = fri_options;
+                    overridden.push("--fri-options");
+                }
+                if !data_sizes.is_empty() {
+                    sweep.encoded_sizes = data_sizes;
+                    overridden.push("--data-sizes");
+                }
+                if !num_queries.is_empty() {
+                    sweep.num_queries = num_queries;
+                    overridden.push("--num-queries");
+                }
+                if !batch_sizes.is_empty() {
+                    sweep.batch_sizes = batch_sizes;
+                    overridden.push("--batch-sizes");
+                }
+                if let Some(preset) = preset {
+                    println!("Using preset {}", preset.name);
+                    if !overridden.is_empty() {
+                        println!("  overridden by {}", overridden.join(", "));
+                    }
+                }
+                run_preflight(&output, skip_preflight);
+                if !frida::run_full_benchmark(
+                    &output,
+                    json_output.as_deref(),
+                    scaling_output.as_deref(),
+                    &sweep,
+                    &budgets,
+                ) {
+                    std::process::exit(1);
+                }
             }
+            BenchmarkSubcommand::Presets => frida::print_presets(),
             BenchmarkSubcommand::Custom {
                 blowup_factor,
                 folding_factor,
@@ -121,21 +388,165 @@ fn main() {
                 data_size,
                 batch_size,
                 num_queries,
+                hash,
                 output,
+                determinism_check,
+                sanity,
+                verify_positions,
+                adaptive_runs,
+                ci,
+                max_runs,
+                warmup,
+                seed,
+                track_memory,
+                memory_output,
+                budgets,
+                profile_runs,
+                profile_output,
             } => {
-                frida::run_custom_benchmark(
+                if determinism_check {
+                    let config = determinism::DeterminismConfig {
+                        blowup_factor,
+                        folding_factor,
+                        max_remainder_degree,
+                        data_size,
+                        batch_size,
+                        num_queries,
+                        hash,
+                        input: seed
+                            .map_or(common::InputSource::Random, common::InputSource::Seeded),
+                    };
+                    if let Err(e) = determinism::run_determinism_check(config) {
+                        eprintln!("Determinism check failed: {e}");
+                        std::process::exit(1);
+                    }
+                }
+                if sanity {
+                    match preflight::run_sanity_checks(
+                        blowup_factor,
+                        folding_factor,
+                        max_remainder_degree,
+                        data_size,
+                        batch_size,
+                        num_queries,
+                    ) {
+                        Ok(report) => report.print("Sanity checks"),
+                        Err(e) => {
+                            eprintln!("Sanity check failed, verification cannot be trusted: {e}");
+                            std::process::exit(1);
+                        }
+                    }
+                }
+                if !verify_positions.is_empty() {
+                    if let Err(e) = positions::run_position_check(
+                        blowup_factor,
+                        folding_factor,
+                        max_remainder_degree,
+                        data_size,
+                        batch_size,
+                        num_queries,
+                        &verify_positions,
+                    ) {
+                        eprintln!("Position check failed: {e}");
+                        std::process::exit(1);
+                    }
+                }
+                if track_memory {
+                    let config = memory::MemoryProfileConfig {
+                        blowup_factor,
+                        folding_factor,
+                        max_remainder_degree,
+                        data_size,
+                        batch_size,
+                        num_queries,
+                        output_path: &memory_output,
+                    };
+                    if let Err(e) = memory::run_memory_profile(config) {
+                        eprintln!("Memory profile failed: {e}");
+                        std::process::exit(1);
+                    }
+                }
+                let run_policy = if adaptive_runs {
+                    common::RunPolicy::Adaptive {
+                        ci_pct: ci,
+                        max_runs,
+                    }
+                } else {
+                    common::RunPolicy::Fixed(profile_runs.unwrap_or(common::RUNS))
+                };
+                let config = frida::CustomFridaBenchmarkConfig {
                     blowup_factor,
                     folding_factor,
                     max_remainder_degree,
                     data_size,
                     batch_size,
                     num_queries,
-                    &output,
-                );
+                    hash,
+                    run_policy,
+                    warmup,
+                    input: seed.map_or(common::InputSource::Random, common::InputSource::Seeded),
+                    budgets: &budgets,
+                    output_path: &output,
+                    profile_output: profile_runs.map(|_| profile_output.as_str()),
+                };
+                match frida::run_custom_benchmark(config) {
+                    Ok(true) => {}
+                    Ok(false) => std::process::exit(1),
+                    Err(e) => {
+                        eprintln!("Custom benchmark failed: {e}");
+                        std::process::exit(1);
+                    }
+                }
+            }
+            BenchmarkSubcommand::Fragmentation {
+                blowup_factor,
+                folding_factor,
+                max_remainder_degree,
+                data_size,
+                batch_size,
+                position_counts,
+                output,
+            } => {
+                let config = fragmentation::FragmentationBenchmarkConfig {
+                    blowup_factor,
+                    folding_factor,
+                    max_remainder_degree,
+                    data_size,
+                    batch_size,
+                    position_counts: &position_counts,
+                    output_path: &output,
+                };
+                if let Err(e) = fragmentation::run_fragmentation_benchmark(config) {
+                    eprintln!("Fragmentation benchmark failed: {e}");
+                    std::process::exit(1);
+                }
+            }
+            BenchmarkSubcommand::Recovery {
+                blowup_factor,
+                folding_factor,
+                max_remainder_degree,
+                data_sizes,
+                output,
+            } => {
+                let config = recovery::RecoveryBenchmarkConfig {
+                    blowup_factor,
+                    folding_factor,
+                    max_remainder_degree,
+                    data_sizes: &data_sizes,
+                    output_path: &output,
+                };
+                if let Err(e) = recovery::run_recovery_benchmark(config) {
+                    eprintln!("Recovery benchmark failed: {e}");
+                    std::process::exit(1);
+                }
             }
         },
         Commands::SingleFrida { subcommand } => match subcommand {
-            SingleFridaSubcommand::Full { output } => {
+            SingleFridaSubcommand::Full {
+                output,
+                skip_preflight,
+            } => {
+                run_preflight(&output, skip_preflight);
                 single_frida::run_full_benchmark(&output);
             }
             SingleFridaSubcommand::Custom {
@@ -157,7 +568,11 @@ fn main() {
             }
         },
         Commands::Defrida { subcommand } => match subcommand {
-            DefridaSubcommand::Full { output } => {
+            DefridaSubcommand::Full {
+                output,
+                skip_preflight,
+            } => {
+                run_preflight(&output, skip_preflight);
                 defrida::run_full_benchmark(&output);
             }
             DefridaSubcommand::Custom {
@@ -169,6 +584,7 @@ fn main() {
                 num_queries,
                 batch_size,
                 output,
+                validator_breakdown,
             } => {
                 let config = defrida::CustomDefridaBenchmarkConfig {
                     blowup_factor,
@@ -179,9 +595,501 @@ fn main() {
                     num_queries,
                     batch_size,
                     output_path: &output,
+                    breakdown_dir: validator_breakdown.as_deref(),
                 };
                 defrida::run_custom_benchmark(config);
             }
         },
+        Commands::Compare {
+            old,
+            new,
+            alpha,
+            json_output,
+        } => {
+            let config = compare::CompareConfig {
+                old_path: &old,
+                new_path: &new,
+                alpha,
+                json_output: json_output.as_deref(),
+            };
+            if let Err(e) = compare::run_compare(config) {
+                eprintln!("Comparison failed: {e}");
+                std::process::exit(1);
+            }
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use clap::CommandFactory;
+
+    fn parse(args: &[&str]) -> Result<Cli, clap::Error> {
+        Cli::try_parse_from(std::iter::once("frida-bench").chain(args.iter().copied()))
+    }
+
+    fn frida_custom<'a>(extra: &[&'a str]) -> Vec<&'a str> {
+        let mut args = vec![
+            "frida",
+            "custom",
+            "--blowup-factor",
+            "2",
+            "--folding-factor",
+            "2",
+            "--max-remainder-degree",
+            "0",
+        ];
+        args.extend_from_slice(extra);
+        args
+    }
+
+    #[test]
+    fn test_cli_definition() {
+        Cli::command().debug_assert();
+    }
+
+    #[test]
+    fn test_empty_args_show_usage() {
+        let err = parse(&[]).err().unwrap();
+        assert_eq!(
+            err.kind(),
+            ErrorKind::DisplayHelpOnMissingArgumentOrSubcommand
+        );
+        assert!(err.use_stderr());
+    }
+
+    #[test]
+    fn test_partial_args() {
+        let err = parse(&["frida"]).err().unwrap();
+        assert_eq!(
+            err.kind(),
+            ErrorKind::DisplayHelpOnMissingArgumentOrSubcommand
+        );
+
+        let err = parse(&frida_custom(&[])).err().unwrap();
+        assert_eq!(err.kind(), ErrorKind::MissingRequiredArgument);
+        assert!(err.to_string().contains("--data-size"));
+    }
+
+    #[test]
+    fn test_malformed_args() {
+        let err = parse(&frida_custom(&["--data-size", "64KB"]))
+            .err()
+            .unwrap();
+        assert_eq!(err.kind(), ErrorKind::ValueValidation);
+
+        let err = parse(&frida_custom(&["--data-size", "0"])).err().unwrap();
+        assert_eq!(err.kind(), ErrorKind::ValueValidation);
+
+        let err = parse(&[
+            "defrida",
+            "custom",
+            "--blowup-factor",
+            "2",
+            "--folding-factor",
+            "2",
+            "--max-remainder-degree",
+            "0",
+            "--data-size",
+            "1024",
+            "--num-validators",
+            "0",
+            "--num-queries",
+            "8",
+        ])
+        .err()
+        .unwrap();
+        assert_eq!(err.kind(), ErrorKind::ValueValidation);
+
+        let err = parse(&["frida", "sweep"]).err().unwrap();
+        assert_eq!(err.kind(), ErrorKind::InvalidSubcommand);
+    }
+
+    #[test]
+    fn test_adaptive_run_args() {
+        fn adaptive(extra: &[&str]) -> Result<Cli, clap::Error> {
+            let mut args = vec!["--data-size", "1024", "--adaptive-runs"];
+            args.extend_from_slice(extra);
+            parse(&frida_custom(&args))
+        }
+        assert!(adaptive(&[]).is_ok());
+        assert!(adaptive(&["--ci", "2.5", "--max-runs", "3"]).is_ok());
+        for bad in [
+            ["--max-runs", "0"],
+            ["--max-runs", "2"],
+            ["--ci", "0"],
+            ["--ci", "-5"],
+            ["--ci", "NaN"],
+        ] {
+            let err = adaptive(&bad).err().unwrap();
+            assert_eq!(err.kind(), ErrorKind::ValueValidation, "{bad:?}");
+        }
+    }
+
+    #[test]
+    fn test_unknown_positional_is_rejected() {
+        let err = parse(&frida_custom(&["--data-size", "1024", "extra"]))
+            .err()
+            .unwrap();
+        assert_eq!(err.kind(), ErrorKind::UnknownArgument);
+    }
+
+    #[test]
+    fn test_valid_custom_args() {
+        let cli = parse(&frida_custom(&["--data-size", "1024"])).unwrap();
+        match cli.command {
+            Commands::Frida {
+                subcommand:
+                    BenchmarkSubcommand::Custom {
+                        data_size,
+                        batch_size,
+                        num_queries,
+                        warmup,
+                        sanity,
+                        hash,
+                        ..
+                    },
+            } => {
+                assert_eq!(data_size, 1024);
+                assert_eq!(batch_size, 1);
+                assert_eq!(num_queries, 32);
+                assert_eq!(warmup, 0);
+                assert!(!sanity);
+                assert_eq!(hash, common::HashFunction::Blake3);
+            }
+            _ => panic!("expected frida custom"),
+        }
+
+        assert!(parse(&frida_custom(&["--data-size", "1024", "--hash", "sha3"])).is_ok());
+        let err = parse(&frida_custom(&["--data-size", "1024", "--hash", "md5"]))
+            .err()
+            .unwrap();
+        assert_eq!(err.kind(), ErrorKind::ValueValidation);
+    }
+
+    #[test]
+    fn test_budget_check_args() {
+        let cli = parse(&frida_custom(&[
+            "--data-size",
+            "1024",
+            "--budget-check",
+            "proof_32<=3s",
+            "--budget-check",
+            "commitment<=250ms",
+        ]))
+        .unwrap();
+        match cli.command {
+            Commands::Frida {
+                subcommand: BenchmarkSubcommand::Custom { budgets, .. },
+            } => {
+                assert_eq!(
+                    budgets,
+                    vec![
+                        frida::PhaseBudget {
+                            phase: "proof_32",
+                            limit_ms: 3000.0
+                        },
+                        frida::PhaseBudget {
+                            phase: "commitment",
+                            limit_ms: 250.0
+                        },
+                    ]
+                );
+            }
+            _ => panic!("expected frida custom"),
+        }
+
+        for bad in ["prove<=3s", "proof_32<3s", "proof_32<=3"] {
+            let err = parse(&frida_custom(&[
+                "--data-size",
+                "1024",
+                "--budget-check",
+                bad,
+            ]))
+            .err()
+            .unwrap();
+            assert_eq!(err.kind(), ErrorKind::ValueValidation, "{bad}");
+        }
+    }
+
+    #[test]
+    fn test_profile_runs_args() {
+        let cli = parse(&frida_custom(&[
+            "--data-size",
+            "1024",
+            "--profile-runs",
+            "100",
+        ]))
+        .unwrap();
+        match cli.command {
+            Commands::Frida {
+                subcommand:
+                    BenchmarkSubcommand::Custom {
+                        profile_runs,
+                        profile_output,
+                        ..
+                    },
+            } => {
+                assert_eq!(profile_runs, Some(100));
+                assert_eq!(profile_output, "bench/results/frida_profile.csv");
+            }
+            _ => panic!("expected frida custom"),
+        }
+
+        let err = parse(&frida_custom(&[
+            "--data-size",
+            "1024",
+            "--profile-runs",
+            "100",
+            "--adaptive-runs",
+        ]))
+        .err()
+        .unwrap();
+        assert_eq!(err.kind(), ErrorKind::ArgumentConflict);
+    }
+
+    #[test]
+    fn test_verify_positions_args() {
+        let cli = parse(&frida_custom(&[
+            "--data-size",
+            "1024",
+            "--verify-positions",
+            "0,17,4095",
+        ]))
+        .unwrap();
+        match cli.command {
+            Commands::Frida {
+                subcommand:
+                    BenchmarkSubcommand::Custom {
+                        verify_positions, ..
+                    },
+            } => assert_eq!(verify_positions, vec![0, 17, 4095]),
+            _ => panic!("expected frida custom"),
+        }
+
+        let err = parse(&frida_custom(&[
+            "--data-size",
+            "1024",
+            "--verify-positions",
+            "0,-1",
+        ]))
+        .err()
+        .unwrap();
+        assert_eq!(err.kind(), ErrorKind::ValueValidation);
+    }
+
+    #[test]
+    fn test_track_memory_args() {
+        let cli = parse(&frida_custom(&["--data-size", "1024", "--track-memory"])).unwrap();
+        match cli.command {
+            Commands::Frida {
+                subcommand:
+                    BenchmarkSubcommand::Custom {
+                        track_memory,
+                        memory_output,
+                        ..
+                    },
+            } => {
+                assert!(track_memory);
+                assert_eq!(memory_output, "bench/results/frida_memory.csv");
+            }
+            _ => panic!("expected frida custom"),
+        }
+
+        let err = parse(&frida_custom(&[
+            "--data-size",
+            "1024",
+            "--memory-output",
+            "memory.csv",
+        ]))
+        .err()
+        .unwrap();
+        assert_eq!(err.kind(), ErrorKind::MissingRequiredArgument);
+    }
+
+    #[test]
+    fn test_fragmentation_defaults() {
+        let cli = parse(&[
+            "frida",
+            "fragmentation",
+            "--blowup-factor",
+            "2",
+            "--folding-factor",
+            "2",
+            "--max-remainder-degree",
+            "0",
+            "--data-size",
+            "1024",
+        ])
+        .unwrap();
+        match cli.command {
+            Commands::Frida {
+                subcommand:
+                    BenchmarkSubcommand::Fragmentation {
+                        position_counts, ..
+                    },
+            } => assert_eq!(position_counts, vec![1, 2, 4, 8, 16, 32]),
+            _ => panic!("expected frida fragmentation"),
+        }
+    }
+
+    #[test]
+    fn test_frida_full_sweep_args() {
+        let cli = parse(&[
+            "frida",
+            "full",
+            "--runs",
+            "3",
+            "--fri-options",
+            "2:2:0,2:8:4",
+            "--data-sizes",
+            "124K,4M",
+            "--num-queries",
+            "32",
+            "--batch-sizes",
+            "4",
+            "--json-output",
+            "results.jsonl",
+            "--scaling-output",
+            "scaling.jsonl",
+            "--warmup",
+            "2",
+            "--seed",
+            "42",
+        ])
+        .unwrap();
+        match cli.command {
+            Commands::Frida {
+                subcommand:
+                    BenchmarkSubcommand::Full {
+                        runs,
+                        fri_options,
+                        data_sizes,
+                        num_queries,
+                        batch_sizes,
+                        json_output,
+                        scaling_output,
+                        warmup,
+                        seed,
+                        ..
+                    },
+            } => {
+                assert_eq!(runs, Some(3));
+                assert_eq!(warmup, Some(2));
+                assert_eq!(seed, Some(42));
+                assert_eq!(fri_options, vec![(2, 2, 0), (2, 8, 4)]);
+                assert_eq!(data_sizes, vec![124 * 1024, 4 * 1024 * 1024]);
+                assert_eq!(num_queries, vec![32]);
+                assert_eq!(batch_sizes, vec![4]);
+                assert_eq!(json_output.as_deref(), Some("results.jsonl"));
+                assert_eq!(scaling_output.as_deref(), Some("scaling.jsonl"));
+            }
+            _ => panic!("expected frida full"),
+        }
+
+        match parse(&["frida", "full"]).unwrap().command {
+            Commands::Frida {
+                subcommand:
+                    BenchmarkSubcommand::Full {
+                        runs,
+                        fri_options,
+                        json_output,
+                        warmup,
+                        seed,
+                        ..
+                    },
+            } => {
+                assert_eq!(runs, None);
+                assert_eq!(warmup, None);
+                assert_eq!(seed, None);
+                assert!(fri_options.is_empty());
+                assert_eq!(json_output, None);
+            }
+            _ => panic!("expected frida full"),
+        }
+
+        match parse(&["frida", "full", "--preset", "rollup-large", "--runs", "2"])
+            .unwrap()
+            .command
+        {
+            Commands::Frida {
+                subcommand: BenchmarkSubcommand::Full { preset, runs, .. },
+            } => {
+                assert_eq!(preset.map(|p| p.name), Some("rollup-large"));
+                assert_eq!(runs, Some(2));
+            }
+            _ => panic!("expected frida full"),
+        }
+        assert!(matches!(
+            parse(&["frida", "presets"]).unwrap().command,
+            Commands::Frida {
+                subcommand: BenchmarkSubcommand::Presets
+            }
+        ));
+
+        for bad in [
+            ["--fri-options", "2:3:0"],
+            ["--data-sizes", "1.5M"],
+            ["--batch-sizes", "1"],
+            ["--runs", "0"],
+            ["--preset", "gaming"],
+        ] {
+            let err = parse(&["frida", "full", bad[0], bad[1]]).err().unwrap();
+            assert_eq!(err.kind(), ErrorKind::ValueValidation, "{bad:?}");
+        }
+    }
+
+    #[test]
+    fn test_skip_preflight_only_on_full() {
+        for benchmark in ["frida", "single-frida", "defrida"] {
+            assert!(parse(&[benchmark, "full", "--skip-preflight"]).is_ok());
+        }
+        let err = parse(&frida_custom(&["--data-size", "1024", "--skip-preflight"]))
+            .err()
+            .unwrap();
+        assert_eq!(err.kind(), ErrorKind::UnknownArgument);
+    }
+
+    #[test]
+    fn test_label_is_global() {
+        let cli = parse(&["--label", "laptop", "frida", "presets"]).unwrap();
+        assert_eq!(cli.label.as_deref(), Some("laptop"));
+        let cli = parse(&["compare", "old.csv", "new.csv", "--label", "ci"]).unwrap();
+        assert_eq!(cli.label.as_deref(), Some("ci"));
+        assert_eq!(parse(&["frida", "presets"]).unwrap().label, None);
+    }
+
+    #[test]
+    fn test_compare_args() {
+        let cli = parse(&["compare", "old.csv", "new.csv"]).unwrap();
+        match cli.command {
+            Commands::Compare {
+                old,
+                new,
+                alpha,
+                json_output,
+            } => {
+                assert_eq!((old.as_str(), new.as_str()), ("old.csv", "new.csv"));
+                assert_eq!(alpha, 0.05);
+                assert_eq!(json_output, None);
+            }
+            _ => panic!("expected compare"),
+        }
+
+        assert!(parse(&["compare", "old.csv", "new.csv", "--alpha", "0.01"]).is_ok());
+        let err = parse(&["compare", "old.csv", "new.csv", "--alpha", "2"])
+            .err()
+            .unwrap();
+        assert_eq!(err.kind(), ErrorKind::ValueValidation);
+        let err = parse(&["compare", "old.csv"]).err().unwrap();
+        assert_eq!(err.kind(), ErrorKind::MissingRequiredArgument);
+    }
+
+    #[test]
+    fn test_help_lists_examples() {
+        let help = Cli::command().render_long_help().to_string();
+        assert!(help.contains("Examples:"));
+        assert!(help.contains("frida-bench frida full"));
     }
 }
@@ -0,0 +1,280 @@
+use std::{
+    alloc::{GlobalAlloc, Layout, System},
+    sync::atomic::{AtomicBool, AtomicIsize, AtomicUsize, Ordering},
+};
+use winter_crypto::ElementHasher;
+use winter_fri::FriOptions;
+use winter_math::FieldElement;
+use winter_rand_utils::rand_vector;
+
+use frida_poc::{
+    prover::{builder::FridaProverBuilder, get_evaluations_from_positions},
+    verifier::das::FridaDasVerifier,
+};
+
+use crate::common::{self, field_names, Blake3F128, Blake3F64, F128Element, F64Element};
+
+static TRACKING: AtomicBool = AtomicBool::new(false);
+static ALLOCATED: AtomicUsize = AtomicUsize::new(0);
+static ALLOCATIONS: AtomicUsize = AtomicUsize::new(0);
+// Signed, since memory allocated before tracking started can be freed while it is on
+static LIVE: AtomicIsize = AtomicIsize::new(0);
+static PEAK: AtomicIsize = AtomicIsize::new(0);
+
+/// System allocator that also counts allocations while [`measure`] is running. Outside of it the
+/// only cost is one relaxed load per call.
+pub struct CountingAllocator;
+
+impl CountingAllocator {
+    fn record(grown: usize, shrunk: usize, new_allocation: bool) {
+        if !TRACKING.load(Ordering::Relaxed) {
+            return;
+        }
+        if new_allocation {
+            ALLOCATIONS.fetch_add(1, Ordering::Relaxed);
+        }
+        ALLOCATED.fetch_add(grown, Ordering::Relaxed);
+        let delta = grown as isize - shrunk as isize;
+        let live = LIVE.fetch_add(delta, Ordering::Relaxed) + delta;
+        PEAK.fetch_max(live, Ordering::Relaxed);
+    }
+}
+
+unsafe impl GlobalAlloc for CountingAllocator {
+    unsafe fn alloc(&self, layout: Layout) -> *mut u8 {
+        let ptr = System.alloc(layout);
+        if !ptr.is_null() {
+            Self::record(layout.size(), 0, true);
+        }
+        ptr
+    }
+
+    unsafe fn alloc_zeroed(&self, layout: Layout) -> *mut u8 {
+        let ptr = System.alloc_zeroed(layout);
+        if !ptr.is_null() {
+            Self::record(layout.size(), 0, true);
+        }
+        ptr
+    }
+
+    unsafe fn dealloc(&self, ptr: *mut u8, layout: Layout) {
+        System.dealloc(ptr, layout);
+        Self::record(0, layout.size(), false);
+    }
+
+    unsafe fn realloc(&self, ptr: *mut u8, layout: Layout, new_size: usize) -> *mut u8 {
+        let new_ptr = System.realloc(ptr, layout, new_size);
+        if !new_ptr.is_null() {
+            Self::record(
+                new_size.saturating_sub(layout.size()),
+                layout.size().saturating_sub(new_size),
+                true,
+            );
+        }
+        new_ptr
+    }
+}
+
+/// Heap activity of one phase.
+#[derive(Debug, Clone, Copy, Default, PartialEq)]
+pub struct MemoryUsage {
+    /// Total bytes requested, including memory freed again within the phase.
+    pub allocated_bytes: usize,
+    pub allocations: usize,
+    /// Largest amount of memory allocated within the phase and live at the same time.
+    pub peak_bytes: usize,
+}
+
+/// Runs `f` with allocation tracking switched on and returns its result along with the heap
+/// activity during the call, on every thread so that the `concurrent` feature is covered. Only
+/// meaningful if [`CountingAllocator`] is the global allocator, and measurements must not overlap.
+pub fn measure<T>(f: impl FnOnce() -> T) -> (T, MemoryUsage) {
+    ALLOCATED.store(0, Ordering::Relaxed);
+    ALLOCATIONS.store(0, Ordering::Relaxed);
+    LIVE.store(0, Ordering::Relaxed);
+    PEAK.store(0, Ordering::Relaxed);
+
+    TRACKING.store(true, Ordering::SeqCst);
+    let result = f();
+    TRACKING.store(false, Ordering::SeqCst);
+
+    let usage = MemoryUsage {
+        allocated_bytes: ALLOCATED.load(Ordering::Relaxed),
+        allocations: ALLOCATIONS.load(Ordering::Relaxed),
+        peak_bytes: PEAK.load(Ordering::Relaxed).max(0) as usize,
+    };
+    (result, usage)
+}
+
+#[derive(Debug)]
+struct MemoryResult {
+    field_type: String,
+    batch_size: usize,
+    data_size_kb: usize,
+    phase: &'static str,
+    usage: MemoryUsage,
+}
+
+impl MemoryResult {
+    fn csv_header() -> String {
+        "field_type,batch_size,data_size_kb,phase,allocated_bytes,allocations,peak_bytes"
+            .to_string()
+    }
+
+    fn to_csv(&self) -> String {
+        format!(
+            "{},{},{},{},{},{},{}",
+            self.field_type,
+            self.batch_size,
+            self.data_size_kb,
+            self.phase,
+            self.usage.allocated_bytes,
+            self.usage.allocations,
+            self.usage.peak_bytes
+        )
+    }
+}
+
+fn profile<E, H>(
+    options: &FriOptions,
+    data_size: usize,
+    batch_size: usize,
+    num_queries: usize,
+    field_name: &str,
+) -> Result<Vec<MemoryResult>, String>
+where
+    E: FieldElement,
+    H: ElementHasher<BaseField = E::BaseField>,
+{
+    let data_list = (0..batch_size)
+        .map(|_| rand_vector::<u8>(data_size))
+        .collect::<Vec<_>>();
+    let mut phases = Vec::new();
+
+    // Erasure coding and commitment happen in the same call, so they are measured together
+    let (commitment, usage) = measure(|| {
+        let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
+        if batch_size > 1 {
+            prover_builder.commit_and_prove_batch(&data_list, num_queries)
+        } else {
+            prover_builder.commit_and_prove(&data_list[0], num_queries)
+        }
+    });
+    let (com, prover) = commitment.map_err(|e| format!("{field_name}: commitment failed: {e}"))?;
+    phases.push(("commitment", usage));
+
+    let domain_size = com.domain_size;
+    let positions = rand_vector::<u64>(32)
+        .into_iter()
+        .map(|v| (v as usize) % domain_size)
+        .collect::<Vec<_>>();
+    let evaluations = get_evaluations_from_positions(
+        prover.get_first_layer_evaluations(),
+        &positions,
+        batch_size,
+        domain_size,
+        options.folding_factor(),
+    );
+    let (proof, usage) = measure(|| prover.open(&positions));
+    phases.push(("proof_32", usage));
+    // Free the prover before verifying, as a verifying node would not hold it
+    drop(prover);
+
+    let (verifier, usage) = measure(|| FridaDasVerifier::<E, H, H>::new(com, options.clone()));
+    let verifier = verifier
+        .map_err(|e| format!("{field_name}: verifier setup failed: {e}"))?
+        .0;
+    phases.push(("verification_setup", usage));
+
+    let (verified, usage) = measure(|| verifier.verify(&proof, &evaluations, &positions));
+    verified.map_err(|e| format!("{field_name}: verification failed: {e}"))?;
+    phases.push(("verification_32", usage));
+
+    Ok(phases
+        .into_iter()
+        .map(|(phase, usage)| MemoryResult {
+            field_type: field_name.to_string(),
+            batch_size,
+            data_size_kb: data_size / 1024,
+            phase,
+            usage,
+        })
+        .collect())
+}
+
+pub struct MemoryProfileConfig<'a> {
+    pub blowup_factor: usize,
+    pub folding_factor: usize,
+    pub max_remainder_degree: usize,
+    pub data_size: usize,
+    pub batch_size: usize,
+    pub num_queries: usize,
+    pub output_path: &'a str,
+}
+
+/// Runs the pipeline once per field type, untimed, recording the heap activity of each phase.
+pub fn run_memory_profile(config: MemoryProfileConfig) -> Result<(), String> {
+    let options = FriOptions::new(
+        config.blowup_factor,
+        config.folding_factor,
+        config.max_remainder_degree,
+    );
+
+    println!("Profiling memory...");
+    let mut results = profile::<F64Element, Blake3F64>(
+        &options,
+        config.data_size,
+        config.batch_size,
+        config.num_queries,
+        field_names::F64,
+    )?;
+    results.extend(profile::<F128Element, Blake3F128>(
+        &options,
+        config.data_size,
+        config.batch_size,
+        config.num_queries,
+        field_names::F128,
+    )?);
+
+    common::save_results_with_header(
+        &results,
+        config.output_path,
+        &MemoryResult::csv_header(),
+        |r| r.to_csv(),
+    )
+    .map_err(|e| format!("failed to save results: {e}"))?;
+    for result in &results {
+        println!(
+            "  {} {}: peak {:.1} KB, {:.1} KB allocated in {} allocations",
+            result.field_type,
+            result.phase,
+            result.usage.peak_bytes as f64 / 1024.0,
+            result.usage.allocated_bytes as f64 / 1024.0,
+            result.usage.allocations
+        );
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_measure_counts_allocations() {
+        // Measurements must not overlap, and the prover tests allocate the most
+        let _prover = common::lock_prover();
+        // Other tests run on other threads and are counted too, so only lower bounds hold. The
+        // peak is not checked: their frees of memory allocated before tracking started can pull
+        // the live count below zero, so it has no lower bound here
+        let ((), usage) = measure(|| {
+            let first = vec![1u8; 1 << 20];
+            let second = vec![1u8; 1 << 20];
+            drop((first, second));
+            let third = vec![1u8; 1 << 20];
+            drop(third);
+        });
+        assert!(usage.allocations >= 3, "{usage:?}");
+        assert!(usage.allocated_bytes >= 3 << 20, "{usage:?}");
+    }
+}
@@ -0,0 +1,179 @@
+use std::time::Instant;
+use winter_crypto::ElementHasher;
+use winter_fri::FriOptions;
+use winter_math::FieldElement;
+use winter_rand_utils::rand_vector;
+
+use frida_poc::{
+    prover::{builder::FridaProverBuilder, get_evaluations_from_positions},
+    verifier::das::FridaDasVerifier,
+};
+
+use crate::common::{field_names, Blake3F128, Blake3F64, F128Element, F64Element};
+
+/// Outcome of opening and verifying a single position.
+#[derive(Debug)]
+pub struct PositionResult {
+    pub field_type: &'static str,
+    pub position: usize,
+    pub verified: bool,
+    pub open_time_ms: f64,
+    pub verification_time_ms: f64,
+}
+
+/// Returns an error naming the valid range if any position falls outside the domain.
+fn check_in_range(positions: &[usize], domain_size: usize) -> Result<(), String> {
+    match positions.iter().find(|&&p| p >= domain_size) {
+        Some(position) => Err(format!(
+            "position {position} is out of range, valid positions are 0..={} (domain size {domain_size})",
+            domain_size - 1
+        )),
+        None => Ok(()),
+    }
+}
+
+fn check<E, H>(
+    options: &FriOptions,
+    data_size: usize,
+    batch_size: usize,
+    num_queries: usize,
+    positions: &[usize],
+    field_name: &'static str,
+) -> Result<Vec<PositionResult>, String>
+where
+    E: FieldElement,
+    H: ElementHasher<BaseField = E::BaseField>,
+{
+    let data_list = (0..batch_size)
+        .map(|_| rand_vector::<u8>(data_size))
+        .collect::<Vec<_>>();
+
+    let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
+    let (com, prover) = if batch_size > 1 {
+        prover_builder.commit_and_prove_batch(&data_list, num_queries)
+    } else {
+        prover_builder.commit_and_prove(&data_list[0], num_queries)
+    }
+    .map_err(|e| format!("{field_name}: commitment failed: {e}"))?;
+
+    let domain_size = com.domain_size;
+    check_in_range(positions, domain_size).map_err(|e| format!("{field_name}: {e}"))?;
+
+    let verifier = FridaDasVerifier::<E, H, H>::new(com, options.clone())
+        .map_err(|e| format!("{field_name}: verifier setup failed: {e}"))?
+        .0;
+
+    let results = positions
+        .iter()
+        .map(|&position| {
+            let evaluations = get_evaluations_from_positions(
+                prover.get_first_layer_evaluations(),
+                &[position],
+                batch_size,
+                domain_size,
+                options.folding_factor(),
+            );
+
+            let timer = Instant::now();
+            let proof = prover.open(&[position]);
+            let open_time_ms = timer.elapsed().as_secs_f64() * 1000.0;
+
+            let timer = Instant::now();
+            let verified = verifier.verify(&proof, &evaluations, &[position]).is_ok();
+            let verification_time_ms = timer.elapsed().as_secs_f64() * 1000.0;
+
+            PositionResult {
+                field_type: field_name,
+                position,
+                verified,
+                open_time_ms,
+                verification_time_ms,
+            }
+        })
+        .collect();
+    Ok(results)
+}
+
+/// Opens and verifies each of `positions` on its own for both field types and reports the outcome
+/// of every position. Fails if a position is outside the evaluation domain or does not verify.
+pub fn run_position_check(
+    blowup_factor: usize,
+    folding_factor: usize,
+    max_remainder_degree: usize,
+    data_size: usize,
+    batch_size: usize,
+    num_queries: usize,
+    positions: &[usize],
+) -> Result<Vec<PositionResult>, String> {
+    let options = FriOptions::new(blowup_factor, folding_factor, max_remainder_degree);
+
+    println!("Verifying positions {positions:?}...");
+    let mut results = check::<F64Element, Blake3F64>(
+        &options,
+        data_size,
+        batch_size,
+        num_queries,
+        positions,
+        field_names::F64,
+    )?;
+    results.extend(check::<F128Element, Blake3F128>(
+        &options,
+        data_size,
+        batch_size,
+        num_queries,
+        positions,
+        field_names::F128,
+    )?);
+
+    for result in &results {
+        println!(
+            "  {} position {}: {} (open {:.3} ms, verify {:.3} ms)",
+            result.field_type,
+            result.position,
+            if result.verified { "ok" } else { "FAILED" },
+            result.open_time_ms,
+            result.verification_time_ms
+        );
+    }
+
+    let failed = results.iter().filter(|r| !r.verified).count();
+    if failed > 0 {
+        return Err(format!(
+            "{failed} of {} position checks failed",
+            results.len()
+        ));
+    }
+    Ok(results)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::common;
+
+    #[test]
+    fn test_check_in_range() {
+        assert!(check_in_range(&[0, 17, 31], 32).is_ok());
+        assert!(check_in_range(&[], 32).is_ok());
+        let err = check_in_range(&[0, 32], 32).unwrap_err();
+        assert_eq!(
+            err,
+            "position 32 is out of range, valid positions are 0..=31 (domain size 32)"
+        );
+    }
+
+    #[test]
+    fn test_run_position_check() {
+        let _prover = common::lock_prover();
+        let results = run_position_check(2, 2, 0, 1024, 2, 8, &[0, 17, 5]).unwrap();
+        assert_eq!(results.len(), 6);
+        assert!(results.iter().all(|r| r.verified));
+        assert_eq!(
+            results.iter().map(|r| r.position).collect::<Vec<_>>(),
+            vec![0, 17, 5, 0, 17, 5]
+        );
+
+        let err = run_position_check(2, 2, 0, 1024, 1, 8, &[1 << 30]).unwrap_err();
+        assert!(err.contains("out of range"), "{err}");
+    }
+}
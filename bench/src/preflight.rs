@@ -0,0 +1,274 @@
+use std::{
+    fs,
+    path::Path,
+    time::{Duration, Instant},
+};
+use winter_crypto::ElementHasher;
+use winter_fri::FriOptions;
+use winter_math::FieldElement;
+use winter_rand_utils::rand_vector;
+use winter_utils::{Deserializable, Serializable};
+
+use frida_poc::{
+    prover::{builder::FridaProverBuilder, get_evaluations_from_positions, proof::FridaProof},
+    verifier::das::FridaDasVerifier,
+};
+
+use crate::common::{self, field_names, Blake3F128, Blake3F64, F128Element, F64Element};
+
+/// Size of each blob pushed through the pipeline; small enough to finish in well under a second.
+const PREFLIGHT_DATA_SIZE: usize = 4 * 1024;
+const PREFLIGHT_BATCH_SIZE: usize = 2;
+const PREFLIGHT_NUM_QUERIES: usize = 8;
+
+/// Timings of the preflight steps, printed before the sweep and never written to the results.
+#[derive(Debug, Default)]
+pub struct PreflightReport {
+    steps: Vec<(String, Duration)>,
+}
+
+impl PreflightReport {
+    fn time<T>(
+        &mut self,
+        step: String,
+        f: impl FnOnce() -> Result<T, String>,
+    ) -> Result<T, String> {
+        let timer = Instant::now();
+        let result = f().map_err(|e| format!("{step}: {e}"))?;
+        self.steps.push((step, timer.elapsed()));
+        Ok(result)
+    }
+
+    pub fn print(&self, checks: &str) {
+        let total = self.steps.iter().map(|(_, d)| *d).sum::<Duration>();
+        println!("{checks} passed in {:.1} ms", total.as_secs_f64() * 1000.0);
+        for (step, duration) in &self.steps {
+            println!("  {step}: {:.3} ms", duration.as_secs_f64() * 1000.0);
+        }
+    }
+}
+
+/// Checks that the results can be written, by creating the output directory and a probe file
+/// next to where the results will go.
+fn check_output_path(output_path: &str) -> Result<(), String> {
+    common::ensure_output_dir(output_path).map_err(|e| e.to_string())?;
+    let probe = Path::new(output_path).with_extension("preflight");
+    fs::write(&probe, b"").map_err(|e| format!("cannot write to {}: {e}", probe.display()))?;
+    fs::remove_file(&probe).map_err(|e| format!("cannot remove {}: {e}", probe.display()))
+}
+
+/// Passes only if verifying deliberately corrupted input failed.
+fn expect_rejection<T, Err>(result: Result<T, Err>, corruption: &str) -> Result<(), String> {
+    match result {
+        Ok(_) => Err(format!("{corruption} was accepted")),
+        Err(_) => Ok(()),
+    }
+}
+
+/// Commits to a batch, opens it, verifies the opening and checks that corrupted evaluations,
+/// proofs and commitments are rejected, so verification timings stand for real checks.
+fn check_pipeline<E, H>(
+    report: &mut PreflightReport,
+    options: &FriOptions,
+    data_size: usize,
+    batch_size: usize,
+    num_queries: usize,
+    field_name: &str,
+) -> Result<(), String>
+where
+    E: FieldElement,
+    H: ElementHasher<BaseField = E::BaseField>,
+{
+    let label = format!("{field_name} batch={batch_size}");
+    let commit = |data_list: &[Vec<u8>]| {
+        let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
+        if batch_size > 1 {
+            prover_builder.commit_and_prove_batch(data_list, num_queries)
+        } else {
+            prover_builder.commit_and_prove(&data_list[0], num_queries)
+        }
+        .map_err(|e| e.to_string())
+    };
+    let new_data = || {
+        (0..batch_size)
+            .map(|_| rand_vector::<u8>(data_size))
+            .collect::<Vec<_>>()
+    };
+
+    let data_list = new_data();
+    let (com, prover) = report.time(format!("{label} commit"), || commit(&data_list))?;
+
+    let domain_size = com.domain_size;
+    let positions = vec![0, 1, domain_size / 2, domain_size - 1];
+    let evaluations_at = |positions: &[usize]| {
+        get_evaluations_from_positions(
+            prover.get_first_layer_evaluations(),
+            positions,
+            batch_size,
+            domain_size,
+            options.folding_factor(),
+        )
+    };
+    let mut evaluations = evaluations_at(&positions);
+    let proof = report.time(format!("{label} open"), || Ok(prover.open(&positions)))?;
+
+    let verifier = report.time(format!("{label} verifier setup"), || {
+        FridaDasVerifier::<E, H, H>::new(com, options.clone())
+            .map(|(verifier, _)| verifier)
+            .map_err(|e| e.to_string())
+    })?;
+    report.time(format!("{label} verify"), || {
+        verifier
+            .verify(&proof, &evaluations, &positions)
+            .map_err(|e| e.to_string())
+    })?;
+
+    // A proof of other positions, checked against these positions' evaluations
+    let other_positions = vec![2, 3, domain_size / 2 + 1, domain_size - 2];
+    let other_proof = prover.open(&other_positions);
+    report.time(format!("{label} swapped proof rejection"), || {
+        expect_rejection(
+            verifier.verify(&other_proof, &evaluations, &positions),
+            "a proof of other positions",
+        )
+    })?;
+
+    let proof_bytes = proof.to_bytes();
+    let mut flipped = proof_bytes.clone();
+    flipped[proof_bytes.len() / 2] ^= 1;
+    report.time(format!("{label} corrupted proof rejection"), || {
+        expect_rejection(
+            FridaProof::read_from_bytes(&flipped)
+                .map_err(|e| e.to_string())
+                .and_then(|p| {
+                    verifier
+                        .verify(&p, &evaluations, &positions)
+                        .map_err(|e| e.to_string())
+                }),
+            "a proof with a flipped byte",
+        )
+    })?;
+    report.time(format!("{label} truncated proof rejection"), || {
+        expect_rejection(
+            FridaProof::read_from_bytes(&proof_bytes[..proof_bytes.len() - 1]),
+            "a truncated proof",
+        )
+    })?;
+
+    let other_data = new_data();
+    let (other_com, _) = commit(&other_data)?;
+    let other_verifier = FridaDasVerifier::<E, H, H>::new(other_com, options.clone())
+        .map_err(|e| e.to_string())?
+        .0;
+    report.time(format!("{label} foreign commitment rejection"), || {
+        expect_rejection(
+            other_verifier.verify(&proof, &evaluations, &positions),
+            "a proof checked against another blob's commitment",
+        )
+    })?;
+
+    evaluations[0] += E::ONE;
+    report.time(format!("{label} tamper rejection"), || {
+        expect_rejection(
+            verifier.verify(&proof, &evaluations, &positions),
+            "a tampered evaluation",
+        )
+    })
+}
+
+/// Runs a minimal end-to-end check of every field type, batched and unbatched, so that setup
+/// problems surface in seconds instead of partway through a sweep.
+pub fn run_preflight(output_path: &str) -> Result<PreflightReport, String> {
+    let mut report = PreflightReport::default();
+    report.time("output path".to_string(), || check_output_path(output_path))?;
+
+    let options = FriOptions::new(2, 2, 0);
+    for batch_size in [1, PREFLIGHT_BATCH_SIZE] {
+        check_fields(
+            &mut report,
+            &options,
+            PREFLIGHT_DATA_SIZE,
+            batch_size,
+            PREFLIGHT_NUM_QUERIES,
+        )?;
+    }
+    Ok(report)
+}
+
+/// Runs the same checks as the preflight with the parameters of a custom benchmark.
+pub fn run_sanity_checks(
+    blowup_factor: usize,
+    folding_factor: usize,
+    max_remainder_degree: usize,
+    data_size: usize,
+    batch_size: usize,
+    num_queries: usize,
+) -> Result<PreflightReport, String> {
+    let options = FriOptions::new(blowup_factor, folding_factor, max_remainder_degree);
+    let mut report = PreflightReport::default();
+    check_fields(&mut report, &options, data_size, batch_size, num_queries)?;
+    Ok(report)
+}
+
+fn check_fields(
+    report: &mut PreflightReport,
+    options: &FriOptions,
+    data_size: usize,
+    batch_size: usize,
+    num_queries: usize,
+) -> Result<(), String> {
+    check_pipeline::<F64Element, Blake3F64>(
+        report,
+        options,
+        data_size,
+        batch_size,
+        num_queries,
+        field_names::F64,
+    )?;
+    check_pipeline::<F128Element, Blake3F128>(
+        report,
+        options,
+        data_size,
+        batch_size,
+        num_queries,
+        field_names::F128,
+    )
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_preflight_passes() {
+        let _prover = common::lock_prover();
+        let dir = std::env::temp_dir().join("frida-bench-preflight-ok");
+        let output = dir.join("results.csv");
+        let report = run_preflight(output.to_str().unwrap()).unwrap();
+        for check in [
+            "swapped proof rejection",
+            "corrupted proof rejection",
+            "truncated proof rejection",
+            "foreign commitment rejection",
+            "tamper rejection",
+        ] {
+            let passed = report.steps.iter().filter(|(s, _)| s.ends_with(check));
+            // Both field types, batched and unbatched
+            assert_eq!(passed.count(), 4, "{check}");
+        }
+        assert!(!output.with_extension("preflight").exists());
+        fs::remove_dir_all(dir).unwrap();
+    }
+
+    #[test]
+    fn test_preflight_rejects_unwritable_output() {
+        let _prover = common::lock_prover();
+        // A regular file where the output directory should be
+        let blocker = std::env::temp_dir().join("frida-bench-preflight-blocker");
+        fs::write(&blocker, b"").unwrap();
+        let output = blocker.join("results.csv");
+        let err = run_preflight(output.to_str().unwrap()).unwrap_err();
+        assert!(err.starts_with("output path"), "{err}");
+        fs::remove_file(blocker).unwrap();
+    }
+}
@@ -0,0 +1,254 @@
+use std::time::{Duration, Instant};
+use winter_crypto::ElementHasher;
+use winter_fri::FriOptions;
+use winter_math::FieldElement;
+use winter_rand_utils::rand_vector;
+use winter_utils::Serializable;
+
+use frida_poc::{
+    core::data::{build_evaluations_from_data, recover_data_from_evaluations},
+    prover::builder::FridaProverBuilder,
+    verifier::das::FridaDasVerifier,
+};
+
+use crate::common::{self, field_names, Blake3F128, Blake3F64, F128Element, F64Element, RUNS};
+
+/// Number of queries used for the commitment, the same in the original and the recommitment.
+const COMMITMENT_QUERIES: usize = 32;
+
+/// Cost for a node to rebuild a blob from the minimal set of evaluations, either verifying the
+/// evaluations against the commitment before decoding or decoding first and recommitting.
+#[derive(Debug)]
+struct RecoveryResult {
+    field_type: String,
+    blowup_factor: usize,
+    folding_factor: usize,
+    max_remainder_degree: usize,
+    data_size_kb: usize,
+    domain_size: usize,
+    min_evaluations: usize,
+    evaluation_bytes: usize,
+    proof_size_bytes: usize,
+    verification_setup_ms: f64,
+    verification_ms: f64,
+    decode_ms: f64,
+    recommit_ms: f64,
+    verify_then_decode_ms: f64,
+    verify_then_decode_bytes: usize,
+    decode_then_recommit_ms: f64,
+    decode_then_recommit_bytes: usize,
+}
+
+impl RecoveryResult {
+    fn csv_header() -> String {
+        "field_type,blowup_factor,folding_factor,max_remainder_degree,data_size_kb,domain_size,min_evaluations,evaluation_bytes,proof_size_bytes,verification_setup_ms,verification_ms,decode_ms,recommit_ms,verify_then_decode_ms,verify_then_decode_bytes,decode_then_recommit_ms,decode_then_recommit_bytes".to_string()
+    }
+
+    fn to_csv(&self) -> String {
+        format!(
+            "{},{},{},{},{},{},{},{},{},{:.3},{:.3},{:.3},{:.3},{:.3},{},{:.3},{}",
+            self.field_type,
+            self.blowup_factor,
+            self.folding_factor,
+            self.max_remainder_degree,
+            self.data_size_kb,
+            self.domain_size,
+            self.min_evaluations,
+            self.evaluation_bytes,
+            self.proof_size_bytes,
+            self.verification_setup_ms,
+            self.verification_ms,
+            self.decode_ms,
+            self.recommit_ms,
+            self.verify_then_decode_ms,
+            self.verify_then_decode_bytes,
+            self.decode_then_recommit_ms,
+            self.decode_then_recommit_bytes
+        )
+    }
+}
+
+/// Returns `count` distinct positions drawn uniformly from the domain.
+fn random_positions(count: usize, domain_size: usize) -> Vec<usize> {
+    let keys = rand_vector::<u64>(domain_size);
+    let mut positions = (0..domain_size).collect::<Vec<_>>();
+    positions.sort_by_key(|&p| keys[p]);
+    positions.truncate(count);
+    positions
+}
+
+fn benchmark<E, H>(
+    options: &FriOptions,
+    data_size: usize,
+    field_name: &str,
+) -> Result<RecoveryResult, String>
+where
+    E: FieldElement,
+    H: ElementHasher<BaseField = E::BaseField>,
+{
+    let mut total_setup_time = Duration::ZERO;
+    let mut total_verify_time = Duration::ZERO;
+    let mut total_decode_time = Duration::ZERO;
+    let mut total_recommit_time = Duration::ZERO;
+    let mut total_proof_size = 0;
+    let mut domain_size = 0;
+
+    for _ in 0..RUNS {
+        let data = rand_vector::<u8>(data_size);
+        let prover_builder = FridaProverBuilder::<E, H>::new(options.clone());
+        let (com, prover) = prover_builder
+            .commit_and_prove(&data, COMMITMENT_QUERIES)
+            .map_err(|e| format!("commitment failed: {e}"))?;
+        let commitment_bytes = com.to_bytes();
+        domain_size = com.domain_size;
+
+        // Any domain_size / blowup_factor evaluations determine the polynomial
+        let positions = random_positions(domain_size / options.blowup_factor(), domain_size);
+        let all_evaluations =
+            build_evaluations_from_data::<E>(&data, domain_size, options.blowup_factor())
+                .map_err(|e| format!("encoding failed: {e}"))?;
+        let evaluations = positions
+            .iter()
+            .map(|&p| all_evaluations[p])
+            .collect::<Vec<_>>();
+        let proof = prover.open(&positions);
+        total_proof_size += proof.size();
+
+        let timer = Instant::now();
+        let verifier = FridaDasVerifier::<E, H, H>::new(com, options.clone())
+            .map_err(|e| format!("verifier setup failed: {e}"))?
+            .0;
+        total_setup_time += timer.elapsed();
+
+        let timer = Instant::now();
+        verifier
+            .verify(&proof, &evaluations, &positions)
+            .map_err(|e| format!("minimal set failed to verify: {e}"))?;
+        total_verify_time += timer.elapsed();
+
+        let timer = Instant::now();
+        let recovered = recover_data_from_evaluations(
+            &evaluations,
+            &positions,
+            domain_size,
+            options.blowup_factor(),
+        )
+        .map_err(|e| format!("decoding failed: {e}"))?;
+        total_decode_time += timer.elapsed();
+        if recovered != data {
+            return Err("decoded data differs from the committed data".to_string());
+        }
+
+        let timer = Instant::now();
+        let (recommitted, _) = prover_builder
+            .commit_and_prove(&recovered, COMMITMENT_QUERIES)
+            .map_err(|e| format!("recommitment failed: {e}"))?;
+        let recommit_matches = recommitted.to_bytes() == commitment_bytes;
+        total_recommit_time += timer.elapsed();
+        if !recommit_matches {
+            return Err("recommitment does not match the original commitment".to_string());
+        }
+    }
+
+    let min_evaluations = domain_size / options.blowup_factor();
+    let evaluation_bytes = min_evaluations * E::ELEMENT_BYTES;
+    let proof_size_bytes = total_proof_size / RUNS;
+    let avg_ms = |total: Duration| total.as_secs_f64() * 1000.0 / RUNS as f64;
+    let verification_setup_ms = avg_ms(total_setup_time);
+    let verification_ms = avg_ms(total_verify_time);
+    let decode_ms = avg_ms(total_decode_time);
+    let recommit_ms = avg_ms(total_recommit_time);
+
+    Ok(RecoveryResult {
+        field_type: field_name.to_string(),
+        blowup_factor: options.blowup_factor(),
+        folding_factor: options.folding_factor(),
+        max_remainder_degree: options.remainder_max_degree(),
+        data_size_kb: data_size / 1024,
+        domain_size,
+        min_evaluations,
+        evaluation_bytes,
+        proof_size_bytes,
+        verification_setup_ms,
+        verification_ms,
+        decode_ms,
+        recommit_ms,
+        verify_then_decode_ms: verification_setup_ms + verification_ms + decode_ms,
+        verify_then_decode_bytes: evaluation_bytes + proof_size_bytes,
+        decode_then_recommit_ms: decode_ms + recommit_ms,
+        decode_then_recommit_bytes: evaluation_bytes,
+    })
+}
+
+pub struct RecoveryBenchmarkConfig<'a> {
+    pub blowup_factor: usize,
+    pub folding_factor: usize,
+    pub max_remainder_degree: usize,
+    pub data_sizes: &'a [usize],
+    pub output_path: &'a str,
+}
+
+/// Runs the recovery experiment for every data size and both field types.
+pub fn run_recovery_benchmark(config: RecoveryBenchmarkConfig) -> Result<(), String> {
+    let options = FriOptions::new(
+        config.blowup_factor,
+        config.folding_factor,
+        config.max_remainder_degree,
+    );
+    let mut results = Vec::new();
+
+    println!("Running Frida recovery benchmark...");
+    println!(
+        "Parameters: blowup={}, folding={}, remainder={}, data sizes={:?}",
+        config.blowup_factor, config.folding_factor, config.max_remainder_degree, config.data_sizes
+    );
+
+    for &data_size in config.data_sizes {
+        results.push(
+            benchmark::<F64Element, Blake3F64>(&options, data_size, field_names::F64)
+                .map_err(|e| format!("{} data={data_size}: {e}", field_names::F64))?,
+        );
+        results.push(
+            benchmark::<F128Element, Blake3F128>(&options, data_size, field_names::F128)
+                .map_err(|e| format!("{} data={data_size}: {e}", field_names::F128))?,
+        );
+    }
+
+    common::save_results_with_header(
+        &results,
+        config.output_path,
+        &RecoveryResult::csv_header(),
+        |r| r.to_csv(),
+    )
+    .expect("Failed to save results");
+    println!("Recovery benchmark completed successfully");
+
+    println!("\nResults Summary (minimal set of evaluations):");
+    for result in &results {
+        println!(
+            "  {} {}KB: verify then decode {:.3} ms / {} bytes, decode then recommit {:.3} ms / {} bytes",
+            result.field_type,
+            result.data_size_kb,
+            result.verify_then_decode_ms,
+            result.verify_then_decode_bytes,
+            result.decode_then_recommit_ms,
+            result.decode_then_recommit_bytes
+        );
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_random_positions_are_distinct_and_in_range() {
+        let mut positions = random_positions(512, 1024);
+        assert_eq!(positions.len(), 512);
+        assert!(positions.iter().all(|&p| p < 1024));
+        positions.sort();
+        positions.dedup();
+        assert_eq!(positions.len(), 512);
+    }
+}
@@ -0,0 +1,133 @@
+//! Deterministic sampling of domain positions, specified so that other implementations, such as
+//! a light client, draw exactly the same positions for the same key.
+//!
+//! All integers are encoded as 8-byte little endian and `||` is concatenation:
+//!
+//! 1. `blob_id = BLAKE3(commitment)`, over the serialized commitment.
+//! 2. `key = BLAKE3("frida-sampler-v1" || seed || blob_id || client_id)`.
+//! 3. `block_i = BLAKE3(key || i)` for `i = 0, 1, 2, ...`, each read as four 8-byte words.
+//! 4. Each word `w`, in order, gives the candidate `w mod domain_size`. A candidate already drawn
+//!    is skipped, and the others are taken until `count` distinct positions are drawn.
+//!
+//! Domain sizes are powers of two, so the reduction is unbiased, and the positions are a uniformly
+//! random selection without replacement; drawing the whole domain gives a permutation of it.
+
+use std::collections::HashSet;
+use winter_crypto::{Digest, Hasher};
+
+use crate::common::Blake3F64;
+
+/// Domain separation tag; a change to the scheme gets a new version.
+const TAG: &[u8] = b"frida-sampler-v1";
+
+type Blake3 = Blake3F64;
+
+/// Returns the identifier of the blob with the given serialized commitment.
+pub fn blob_id(commitment: &[u8]) -> [u8; 32] {
+    Blake3::hash(commitment).as_bytes()
+}
+
+/// Draws `count` distinct positions in a domain of `domain_size` for one client.
+pub fn sample_positions(
+    seed: u64,
+    blob_id: &[u8; 32],
+    client_id: u64,
+    domain_size: usize,
+    count: usize,
+) -> Vec<usize> {
+    assert!(
+        domain_size.is_power_of_two(),
+        "Domain size {domain_size} is not a power of two"
+    );
+    assert!(
+        count <= domain_size,
+        "Cannot draw {count} distinct positions from a domain of {domain_size}"
+    );
+    let key = Blake3::hash(&[TAG, &seed.to_le_bytes(), blob_id, &client_id.to_le_bytes()].concat())
+        .as_bytes();
+    let mut drawn = HashSet::with_capacity(count);
+    (0u64..)
+        .flat_map(|i| {
+            let block = Blake3::hash(&[&key[..], &i.to_le_bytes()].concat()).as_bytes();
+            (0..4).map(move |word| {
+                u64::from_le_bytes(block[word * 8..word * 8 + 8].try_into().unwrap())
+            })
+        })
+        .map(|word| (word % domain_size as u64) as usize)
+        .filter(|&position| drawn.insert(position))
+        .take(count)
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn blob_id_hex(commitment: &[u8]) -> String {
+        blob_id(commitment).map(|b| format!("{b:02x}")).concat()
+    }
+
+    /// Published in the README; an external implementation must reproduce these exactly.
+    #[test]
+    fn test_vectors() {
+        let blob_id = std::array::from_fn::<u8, 32, _>(|i| i as u8);
+        assert_eq!(
+            sample_positions(42, &blob_id, 0, 1024, 10),
+            vec![679, 485, 193, 430, 880, 131, 596, 168, 987, 338]
+        );
+        assert_eq!(
+            sample_positions(42, &blob_id, 7, 1024, 10),
+            vec![390, 422, 157, 934, 177, 317, 365, 553, 486, 470]
+        );
+        assert_eq!(
+            sample_positions(43, &blob_id, 0, 1024, 10),
+            vec![19, 975, 465, 441, 800, 875, 666, 914, 38, 52]
+        );
+        assert_eq!(
+            sample_positions(42, &blob_id, 0, 1 << 20, 5),
+            vec![1022631, 196069, 141505, 741806, 147312]
+        );
+        assert!(sample_positions(42, &blob_id, 0, 1024, 0).is_empty());
+        // The fourth and sixth candidates, 13 and 38, repeat earlier ones and are skipped
+        assert_eq!(
+            sample_positions(0, &blob_id, 0, 64, 8),
+            vec![38, 29, 13, 7, 49, 6, 47, 18]
+        );
+        assert_eq!(
+            sample_positions(42, &blob_id, 0, 16, 16),
+            vec![7, 5, 1, 14, 0, 3, 4, 8, 11, 2, 6, 10, 12, 13, 9, 15]
+        );
+        assert_eq!(
+            blob_id_hex(b"frida"),
+            "0ae66da5513ae4d878e83efb59244ac7ee67b9520934b4d62423dab728efa89e"
+        );
+    }
+
+    #[test]
+    fn test_positions_are_a_prefix_of_longer_draws() {
+        let blob_id = blob_id(b"commitment");
+        let long = sample_positions(1, &blob_id, 3, 64, 33);
+        assert_eq!(sample_positions(1, &blob_id, 3, 64, 5), long[..5]);
+        assert!(long.iter().all(|&p| p < 64));
+    }
+
+    #[test]
+    fn test_positions_are_distinct() {
+        let blob_id = blob_id(b"commitment");
+        let mut all = sample_positions(9, &blob_id, 0, 32, 32);
+        all.sort_unstable();
+        assert_eq!(all, (0..32).collect::<Vec<_>>());
+    }
+
+    #[test]
+    #[should_panic(expected = "Cannot draw 9 distinct positions")]
+    fn test_rejects_count_above_domain_size() {
+        sample_positions(1, &[0; 32], 0, 8, 9);
+    }
+
+    #[test]
+    #[should_panic(expected = "not a power of two")]
+    fn test_rejects_non_power_of_two_domain() {
+        sample_positions(1, &[0; 32], 0, 1000, 1);
+    }
+}
@@ -8,7 +8,9 @@ use frida_poc::{
     constants, core::data::encoded_data_element_count, prover::builder::FridaProverBuilder,
 };
 
-use crate::common::{self, field_names, Blake3F128, Blake3F64, F128Element, F64Element, RUNS};
+use crate::common::{
+    self, field_names, Blake3F128, Blake3F64, F128Element, F64Element, SweepTotals, RUNS,
+};
 
 #[derive(Debug)]
 struct SingleFridaBenchmarkResult {
@@ -19,19 +21,22 @@ struct SingleFridaBenchmarkResult {
     max_remainder_degree: usize,
     data_size_kb: usize,
     domain_size: usize,
+    log2_domain_size: u32,
+    extension_factor: f64,
     single_proof_time_ms: f64,
     single_proof_size_bytes: usize,
     total_proof_size_estimate_mb: f64,
+    work: SweepTotals,
 }
 
 impl SingleFridaBenchmarkResult {
     fn csv_header() -> String {
-        "field_type,batch_size,blowup_factor,folding_factor,max_remainder_degree,data_size_kb,domain_size,single_proof_time_ms,single_proof_size_bytes,total_proof_size_estimate_mb".to_string()
+        "field_type,batch_size,blowup_factor,folding_factor,max_remainder_degree,data_size_kb,domain_size,log2_domain_size,extension_factor,single_proof_time_ms,single_proof_size_bytes,total_proof_size_estimate_mb".to_string()
     }
 
     fn to_csv(&self) -> String {
         format!(
-            "{},{},{},{},{},{},{},{:.3},{},{:.3}",
+            "{},{},{},{},{},{},{},{},{:.3},{:.3},{},{:.3}",
             self.field_type,
             self.batch_size,
             self.blowup_factor,
@@ -39,6 +44,8 @@ impl SingleFridaBenchmarkResult {
             self.max_remainder_degree,
             self.data_size_kb,
             self.domain_size,
+            self.log2_domain_size,
+            self.extension_factor,
             self.single_proof_time_ms,
             self.single_proof_size_bytes,
             self.total_proof_size_estimate_mb
@@ -63,6 +70,8 @@ where
         encoded_element_count.next_power_of_two() * options.blowup_factor(),
         constants::MIN_DOMAIN_SIZE,
     );
+    let (log2_domain_size, extension_factor) =
+        common::domain_geometry(domain_size, encoded_element_count);
 
     for _ in 0..RUNS {
         let data = rand_vector::<u8>(data_size);
@@ -93,9 +102,16 @@ where
         max_remainder_degree: options.remainder_max_degree(),
         data_size_kb: data_size / 1024,
         domain_size,
+        log2_domain_size,
+        extension_factor,
         single_proof_time_ms: avg_proof_time_ms,
         single_proof_size_bytes: avg_proof_size_bytes,
         total_proof_size_estimate_mb,
+        work: SweepTotals {
+            data_bytes: RUNS * data_size,
+            proofs: RUNS,
+            verifications: 0,
+        },
     }
 }
 
@@ -117,6 +133,7 @@ where
         (max_data_len * options.blowup_factor()).next_power_of_two(),
         constants::MIN_DOMAIN_SIZE,
     );
+    let (log2_domain_size, extension_factor) = common::domain_geometry(domain_size, max_data_len);
 
     for _ in 0..RUNS {
         let mut data_list = vec![];
@@ -151,9 +168,16 @@ where
         max_remainder_degree: options.remainder_max_degree(),
         data_size_kb: data_size / 1024,
         domain_size,
+        log2_domain_size,
+        extension_factor,
         single_proof_time_ms: avg_proof_time_ms,
         single_proof_size_bytes: avg_proof_size_bytes,
         total_proof_size_estimate_mb,
+        work: SweepTotals {
+            data_bytes: RUNS * batch_size * data_size,
+            proofs: RUNS,
+            verifications: 0,
+        },
     }
 }
 
@@ -184,6 +208,7 @@ pub fn run_full_benchmark(output_path: &str) {
     let mut results = Vec::new();
 
     println!("Running full Single Frida benchmark suite...");
+    let sweep_start = Instant::now();
     let total_configs = fri_options.len() * data_sizes.len() * batch_sizes.len() * 2;
     println!("Total configurations: {total_configs}");
 
@@ -260,6 +285,10 @@ pub fn run_full_benchmark(output_path: &str) {
         "Single Frida benchmark completed with {} successful results",
         results.len()
     );
+    let totals = results
+        .iter()
+        .fold(SweepTotals::default(), |acc, r| acc + r.work);
+    println!("{}", totals.summary(sweep_start.elapsed()));
 }
 
 pub fn run_custom_benchmark(
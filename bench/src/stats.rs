@@ -0,0 +1,530 @@
+use std::time::{Duration, Instant};
+
+/// Number of groups used for the median-of-means estimate.
+pub const MEDIAN_OF_MEANS_GROUPS: usize = 5;
+
+/// Number of empty timed regions measured when calibrating the timer overhead.
+pub const TIMER_CALIBRATION_SAMPLES: usize = 10_000;
+
+/// A phase measurement is low-confidence if it is within this multiple of the timer overhead.
+pub const LOW_CONFIDENCE_FACTOR: u32 = 10;
+
+/// Two-sided 95% Student-t critical values for 1 to 30 degrees of freedom.
+const T_975: [f64; 30] = [
+    12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228, 2.201, 2.179, 2.160,
+    2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086, 2.080, 2.074, 2.069, 2.064, 2.060, 2.056,
+    2.052, 2.048, 2.045, 2.042,
+];
+
+pub fn mean(samples: &[f64]) -> f64 {
+    if samples.is_empty() {
+        return 0.0;
+    }
+    samples.iter().sum::<f64>() / samples.len() as f64
+}
+
+/// Sample standard deviation (with Bessel's correction).
+pub fn stddev(samples: &[f64]) -> f64 {
+    if samples.len() < 2 {
+        return 0.0;
+    }
+    let m = mean(samples);
+    let var = samples.iter().map(|x| (x - m).powi(2)).sum::<f64>() / (samples.len() - 1) as f64;
+    var.sqrt()
+}
+
+/// Smallest sample, or zero if there are none.
+pub fn min(samples: &[f64]) -> f64 {
+    if samples.is_empty() {
+        return 0.0;
+    }
+    samples.iter().copied().fold(f64::INFINITY, f64::min)
+}
+
+/// Largest sample, or zero if there are none.
+pub fn max(samples: &[f64]) -> f64 {
+    samples.iter().copied().fold(0.0, f64::max)
+}
+
+/// Nearest-rank percentile of `samples`, for `pct` in (0, 100].
+///
+/// With fewer than 100 samples the 99th percentile is the maximum, which is the conservative
+/// answer for a latency bound.
+pub fn percentile(samples: &[f64], pct: f64) -> f64 {
+    if samples.is_empty() {
+        return 0.0;
+    }
+    let mut sorted = samples.to_vec();
+    sorted.sort_by(f64::total_cmp);
+    let rank = (pct / 100.0 * sorted.len() as f64).ceil() as usize;
+    sorted[rank.clamp(1, sorted.len()) - 1]
+}
+
+/// Two-sided 95% Student-t critical value for `df` degrees of freedom.
+///
+/// Beyond the table the value of the nearest smaller tabulated df is used, which keeps the
+/// interval conservative.
+pub fn student_t_975(df: usize) -> f64 {
+    match df {
+        0 => f64::INFINITY,
+        1..=30 => T_975[df - 1],
+        31..=40 => 2.042,
+        41..=60 => 2.021,
+        61..=120 => 2.000,
+        _ => 1.980,
+    }
+}
+
+/// Half-width of the 95% confidence interval of the mean.
+pub fn ci_half_width(samples: &[f64]) -> f64 {
+    let n = samples.len();
+    if n < 2 {
+        return f64::INFINITY;
+    }
+    student_t_975(n - 1) * stddev(samples) / (n as f64).sqrt()
+}
+
+/// Half-width of the 95% confidence interval relative to the mean, as a percentage.
+pub fn ci_relative_pct(samples: &[f64]) -> f64 {
+    let m = mean(samples);
+    if m == 0.0 {
+        return if stddev(samples) == 0.0 && samples.len() >= 2 {
+            0.0
+        } else {
+            f64::INFINITY
+        };
+    }
+    ci_half_width(samples) / m.abs() * 100.0
+}
+
+/// Splits `samples` into at most `num_groups` contiguous groups and returns the median of the
+/// group means.
+pub fn median_of_means(samples: &[f64], num_groups: usize) -> f64 {
+    if samples.is_empty() || num_groups == 0 {
+        return 0.0;
+    }
+    let group_len = samples.len().div_ceil(num_groups);
+    let mut means = samples.chunks(group_len).map(mean).collect::<Vec<_>>();
+    means.sort_by(f64::total_cmp);
+    let mid = means.len() / 2;
+    if means.len() % 2 == 0 {
+        (means[mid - 1] + means[mid]) / 2.0
+    } else {
+        means[mid]
+    }
+}
+
+/// Returns the index of the first sample from which the rolling mean over `window` samples stays
+/// within `tolerance_pct` of the final rolling mean.
+///
+/// The stable stretch must cover at least two full windows; otherwise the series has not settled
+/// (or is still drifting) and `None` is returned.
+pub fn stabilization_index(series: &[f64], window: usize, tolerance_pct: f64) -> Option<usize> {
+    if window == 0 || series.len() < 2 * window {
+        return None;
+    }
+    let rolling = series.windows(window).map(mean).collect::<Vec<_>>();
+    let reference = rolling[rolling.len() - 1];
+    let tolerance = reference.abs() * tolerance_pct / 100.0;
+    let start = rolling
+        .iter()
+        .rposition(|m| (m - reference).abs() > tolerance)
+        .map_or(0, |i| i + 1);
+    (start + 2 * window <= series.len()).then_some(start)
+}
+
+/// Returns the median of `samples` durations produced by `measure_empty_region`.
+pub fn calibrate_with<F>(samples: usize, mut measure_empty_region: F) -> Duration
+where
+    F: FnMut() -> Duration,
+{
+    let mut durations = (0..samples)
+        .map(|_| measure_empty_region())
+        .collect::<Vec<_>>();
+    if durations.is_empty() {
+        return Duration::ZERO;
+    }
+    durations.sort();
+    durations[durations.len() / 2]
+}
+
+/// Measures the cost of timing an empty region with `Instant`, the way the benchmarks time their
+/// phases.
+pub fn calibrate_timer_overhead() -> Duration {
+    calibrate_with(TIMER_CALIBRATION_SAMPLES, || {
+        let timer = Instant::now();
+        timer.elapsed()
+    })
+}
+
+/// Returns true if `measured` is too close to the timer overhead to be meaningful.
+pub fn is_low_confidence(measured: Duration, timer_overhead: Duration) -> bool {
+    measured < timer_overhead * LOW_CONFIDENCE_FACTOR
+}
+
+/// Returns the names of the phases whose average time, in milliseconds, is low-confidence.
+pub fn low_confidence_phases(
+    phases_ms: &[(&'static str, f64)],
+    timer_overhead: Duration,
+) -> Vec<&'static str> {
+    phases_ms
+        .iter()
+        .filter(|(_, ms)| is_low_confidence(Duration::from_secs_f64(ms / 1000.0), timer_overhead))
+        .map(|(name, _)| *name)
+        .collect()
+}
+
+/// Outcome of a two-sided Mann-Whitney U test of `new` samples against `old` ones.
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub struct MannWhitney {
+    /// Number of (old, new) pairs in which the new sample is larger, ties counting half.
+    pub u: f64,
+    pub p_value: f64,
+    /// Rank-biserial correlation, from -1 (every new sample smaller) to 1 (every one larger).
+    pub effect_size: f64,
+}
+
+/// Mann-Whitney U test using the normal approximation with tie and continuity corrections, as
+/// SciPy's `mannwhitneyu(..., method="asymptotic")` does. It compares whole distributions, so it
+/// picks up a fatter tail that leaves the mean unchanged; the approximation wants about eight or
+/// more samples on each side.
+pub fn mann_whitney_u(old: &[f64], new: &[f64]) -> MannWhitney {
+    if old.is_empty() || new.is_empty() {
+        return MannWhitney {
+            u: 0.0,
+            p_value: 1.0,
+            effect_size: 0.0,
+        };
+    }
+    let (n_old, n_new) = (old.len() as f64, new.len() as f64);
+    let mut pooled = old
+        .iter()
+        .map(|&x| (x, false))
+        .chain(new.iter().map(|&x| (x, true)))
+        .collect::<Vec<_>>();
+    pooled.sort_by(|a, b| a.0.total_cmp(&b.0));
+
+    // Tied samples share the average of the ranks they span
+    let mut new_rank_sum = 0.0;
+    let mut tie_sum = 0.0;
+    let mut start = 0;
+    while start < pooled.len() {
+        let end = start
+            + pooled[start..]
+                .iter()
+                .take_while(|(x, _)| *x == pooled[start].0)
+                .count();
+        let rank = (start + 1 + end) as f64 / 2.0;
+        let new_count = pooled[start..end]
+            .iter()
+            .filter(|(_, is_new)| *is_new)
+            .count();
+        new_rank_sum += rank * new_count as f64;
+        let ties = (end - start) as f64;
+        tie_sum += ties.powi(3) - ties;
+        start = end;
+    }
+
+    let u = new_rank_sum - n_new * (n_new + 1.0) / 2.0;
+    let n = n_old + n_new;
+    let variance = n_old * n_new / 12.0 * (n + 1.0 - tie_sum / (n * (n - 1.0)));
+    let p_value = if variance > 0.0 {
+        let z = ((u - n_old * n_new / 2.0).abs() - 0.5).max(0.0) / variance.sqrt();
+        (2.0 * normal_upper_tail(z)).min(1.0)
+    } else {
+        // Every sample is equal
+        1.0
+    };
+    MannWhitney {
+        u,
+        p_value,
+        effect_size: 2.0 * u / (n_old * n_new) - 1.0,
+    }
+}
+
+/// P(Z > z) for a standard normal Z, to within about 1e-7 (Numerical Recipes' `erfcc`).
+fn normal_upper_tail(z: f64) -> f64 {
+    let x = z.abs() / std::f64::consts::SQRT_2;
+    let t = 1.0 / (1.0 + 0.5 * x);
+    let poly = -x * x - 1.265_512_23
+        + t * (1.000_023_68
+            + t * (0.374_091_96
+                + t * (0.096_784_18
+                    + t * (-0.186_288_06
+                        + t * (0.278_868_07
+                            + t * (-1.135_203_98
+                                + t * (1.488_515_87 + t * (-0.822_152_23 + t * 0.170_872_77))))))));
+    let erfc = t * poly.exp();
+    if z >= 0.0 {
+        erfc / 2.0
+    } else {
+        1.0 - erfc / 2.0
+    }
+}
+
+/// Least-squares fit of `ln y = exponent * ln x + c`, i.e. of how `y` grows with `x`.
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub struct PowerFit {
+    pub exponent: f64,
+    /// Coefficient of determination of the fit in log-log space.
+    pub r_squared: f64,
+}
+
+/// Fits a power law through `(x, y)` points. Returns `None` unless there are at least two distinct
+/// x values and every value is positive.
+pub fn power_fit(points: &[(f64, f64)]) -> Option<PowerFit> {
+    if points.len() < 2 || points.iter().any(|&(x, y)| !(x > 0.0 && y > 0.0)) {
+        return None;
+    }
+    let (xs, ys): (Vec<f64>, Vec<f64>) = points.iter().map(|&(x, y)| (x.ln(), y.ln())).unzip();
+    let (mean_x, mean_y) = (mean(&xs), mean(&ys));
+    let (mut sxx, mut sxy, mut syy) = (0.0, 0.0, 0.0);
+    for (x, y) in xs.iter().zip(&ys) {
+        sxx += (x - mean_x).powi(2);
+        sxy += (x - mean_x) * (y - mean_y);
+        syy += (y - mean_y).powi(2);
+    }
+    if sxx == 0.0 {
+        return None;
+    }
+    Some(PowerFit {
+        exponent: sxy / sxx,
+        // A flat line is fitted exactly
+        r_squared: if syy == 0.0 {
+            1.0
+        } else {
+            sxy * sxy / (sxx * syy)
+        },
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const EPS: f64 = 1e-3;
+
+    #[test]
+    fn test_power_fit() {
+        let quadratic = [1.0, 2.0, 4.0, 8.0].map(|x| (x, 3.0 * x * x));
+        let fit = power_fit(&quadratic).unwrap();
+        assert!((fit.exponent - 2.0).abs() < 1e-9);
+        assert!((fit.r_squared - 1.0).abs() < 1e-9);
+
+        // n log n over a typical size sweep looks slightly worse than linear
+        let n_log_n = [17.0, 18.0, 19.0, 20.0, 21.0].map(|log_n| {
+            let n = 2f64.powf(log_n);
+            (n, n * log_n)
+        });
+        let fit = power_fit(&n_log_n).unwrap();
+        assert!(fit.exponent > 1.05 && fit.exponent < 1.1, "{fit:?}");
+        assert!(fit.r_squared > 0.99);
+
+        let flat = power_fit(&[(1.0, 5.0), (2.0, 5.0)]).unwrap();
+        assert_eq!(
+            flat,
+            PowerFit {
+                exponent: 0.0,
+                r_squared: 1.0
+            }
+        );
+    }
+
+    #[test]
+    fn test_power_fit_degenerate_points() {
+        assert_eq!(power_fit(&[]), None);
+        assert_eq!(power_fit(&[(1.0, 1.0)]), None);
+        assert_eq!(power_fit(&[(2.0, 1.0), (2.0, 3.0)]), None);
+        assert_eq!(power_fit(&[(1.0, 0.0), (2.0, 3.0)]), None);
+        assert_eq!(power_fit(&[(1.0, 1.0), (2.0, f64::NAN)]), None);
+    }
+
+    #[test]
+    fn test_mean_and_stddev() {
+        let samples = [2.0, 4.0, 4.0, 4.0, 5.0, 5.0, 7.0, 9.0];
+        assert!((mean(&samples) - 5.0).abs() < EPS);
+        // Population stddev is 2; the sample stddev is 2 * sqrt(8 / 7).
+        assert!((stddev(&samples) - 2.138).abs() < EPS);
+        assert_eq!(stddev(&[1.0]), 0.0);
+    }
+
+    #[test]
+    fn test_max_and_percentile() {
+        let samples = (1..=200).rev().map(f64::from).collect::<Vec<_>>();
+        assert_eq!(max(&samples), 200.0);
+        assert_eq!(min(&samples), 1.0);
+        assert_eq!(percentile(&samples, 99.0), 198.0);
+        assert_eq!(percentile(&samples, 50.0), 100.0);
+        assert_eq!(percentile(&samples, 100.0), 200.0);
+        assert_eq!(percentile(&samples, 0.0), 1.0);
+    }
+
+    #[test]
+    fn test_percentile_small_samples() {
+        let samples = [3.0, 9.0, 1.0];
+        assert_eq!(percentile(&samples, 99.0), 9.0);
+        assert_eq!(percentile(&[], 99.0), 0.0);
+        assert_eq!(max(&[]), 0.0);
+        assert_eq!(min(&[]), 0.0);
+    }
+
+    #[test]
+    fn test_mann_whitney_known_datasets() {
+        // Fully separated samples: U = 25, z = 12 / sqrt(22.917)
+        let result = mann_whitney_u(&[1.0, 2.0, 3.0, 4.0, 5.0], &[6.0, 7.0, 8.0, 9.0, 10.0]);
+        assert_eq!(result.u, 25.0);
+        assert_eq!(result.effect_size, 1.0);
+        assert!((result.p_value - 0.012186).abs() < 1e-5, "{result:?}");
+
+        // The example in SciPy's documentation, with its asymptotic p-value
+        let result = mann_whitney_u(&[20.0, 11.0, 17.0, 12.0], &[19.0, 22.0, 16.0, 29.0, 24.0]);
+        assert_eq!(result.u, 17.0);
+        assert!((result.p_value - 0.111347).abs() < 1e-5, "{result:?}");
+
+        // Ties across both samples: ranks 1, 3, 3, 3, 5.5, 5.5, so the new samples' rank sum is
+        // 8.5, and the tied groups of three and two add 24 + 6 to the tie correction
+        let result = mann_whitney_u(&[1.0, 2.0, 2.0, 4.0], &[2.0, 4.0]);
+        assert_eq!(result.u, 5.5);
+        assert_eq!(result.effect_size, 0.375);
+        let variance = 8.0 / 12.0 * (7.0 - 30.0 / 30.0);
+        let z = (5.5 - 4.0 - 0.5) / f64::sqrt(variance);
+        assert!((result.p_value - 2.0 * normal_upper_tail(z)).abs() < 1e-12);
+    }
+
+    #[test]
+    fn test_mann_whitney_degenerate_samples() {
+        let result = mann_whitney_u(&[3.0; 5], &[3.0; 7]);
+        assert_eq!(result.p_value, 1.0);
+        assert_eq!(result.effect_size, 0.0);
+        assert_eq!(mann_whitney_u(&[], &[1.0]).p_value, 1.0);
+    }
+
+    #[test]
+    fn test_normal_upper_tail() {
+        assert!((normal_upper_tail(0.0) - 0.5).abs() < 1e-7);
+        assert!((normal_upper_tail(1.959_964) - 0.025).abs() < 1e-7);
+        assert!((normal_upper_tail(-1.0) - 0.841_344_7).abs() < 1e-7);
+    }
+
+    #[test]
+    fn test_ci_half_width_known_sample() {
+        // mean 3, s = sqrt(2.5), t(4) = 2.776
+        let samples = [1.0, 2.0, 3.0, 4.0, 5.0];
+        let expected = 2.776 * 2.5f64.sqrt() / 5f64.sqrt();
+        assert!((ci_half_width(&samples) - expected).abs() < EPS);
+        assert!((ci_relative_pct(&samples) - expected / 3.0 * 100.0).abs() < EPS);
+    }
+
+    #[test]
+    fn test_ci_constant_samples() {
+        let samples = [7.5; 4];
+        assert_eq!(ci_half_width(&samples), 0.0);
+        assert_eq!(ci_relative_pct(&samples), 0.0);
+        assert_eq!(ci_relative_pct(&[0.0, 0.0]), 0.0);
+    }
+
+    #[test]
+    fn test_ci_undefined_for_single_sample() {
+        assert!(ci_half_width(&[1.0]).is_infinite());
+        assert!(ci_relative_pct(&[1.0]).is_infinite());
+    }
+
+    #[test]
+    fn test_ci_shrinks_with_more_samples() {
+        let small = [9.0, 11.0, 9.0, 11.0];
+        let large = small.repeat(8);
+        assert!(ci_half_width(&large) < ci_half_width(&small));
+    }
+
+    #[test]
+    fn test_student_t_975() {
+        assert!(student_t_975(0).is_infinite());
+        assert!((student_t_975(1) - 12.706).abs() < EPS);
+        assert!((student_t_975(30) - 2.042).abs() < EPS);
+        // t(35) is 2.030, so t(30) rather than t(40) keeps the interval conservative
+        assert!((student_t_975(35) - 2.042).abs() < EPS);
+        assert!((student_t_975(40) - 2.042).abs() < EPS);
+        assert!((student_t_975(41) - 2.021).abs() < EPS);
+        assert!(student_t_975(1000) > 1.959);
+        for df in 1..200 {
+            assert!(student_t_975(df + 1) <= student_t_975(df));
+        }
+    }
+
+    #[test]
+    fn test_median_of_means_ignores_outlier_group() {
+        let mut samples = vec![1.0; 8];
+        samples.extend([100.0, 100.0]);
+        // Groups of 2: means are 1, 1, 1, 1, 100.
+        assert!((median_of_means(&samples, 5) - 1.0).abs() < EPS);
+        assert!(mean(&samples) > 20.0);
+    }
+
+    #[test]
+    fn test_median_of_means_even_group_count() {
+        let samples = [1.0, 2.0, 3.0, 4.0];
+        assert!((median_of_means(&samples, 4) - 2.5).abs() < EPS);
+        assert!((median_of_means(&samples, 1) - 2.5).abs() < EPS);
+        assert_eq!(median_of_means(&[], 5), 0.0);
+    }
+
+    #[test]
+    fn test_stabilization_after_warmup() {
+        let mut series = vec![10.0; 10];
+        series.extend([1.0; 90]);
+        assert_eq!(stabilization_index(&series, 10, 2.0), Some(10));
+
+        // Small noise within tolerance does not delay stabilization
+        let noisy = (0..100)
+            .map(|i| if i % 2 == 0 { 1.005 } else { 0.995 })
+            .collect::<Vec<_>>();
+        assert_eq!(stabilization_index(&noisy, 10, 2.0), Some(0));
+    }
+
+    #[test]
+    fn test_stabilization_not_reached() {
+        // Still drifting by 1% per run at the end of the series
+        let drifting = (1..=100).map(f64::from).collect::<Vec<_>>();
+        assert_eq!(stabilization_index(&drifting, 10, 2.0), None);
+
+        // Settles too late to leave two stable windows
+        let mut late = vec![10.0; 85];
+        late.extend([1.0; 15]);
+        assert_eq!(stabilization_index(&late, 10, 2.0), None);
+
+        assert_eq!(stabilization_index(&[1.0; 19], 10, 2.0), None);
+        assert_eq!(stabilization_index(&[1.0; 5], 0, 2.0), None);
+    }
+
+    #[test]
+    fn test_calibrate_with_fake_clock() {
+        let mut ticks = [5u64, 1, 9, 3, 7].into_iter().cycle();
+        let overhead = calibrate_with(5, || Duration::from_nanos(ticks.next().unwrap()));
+        assert_eq!(overhead, Duration::from_nanos(5));
+        assert_eq!(calibrate_with(0, || Duration::from_secs(1)), Duration::ZERO);
+    }
+
+    #[test]
+    fn test_low_confidence_threshold() {
+        let overhead = Duration::from_nanos(40);
+        assert!(is_low_confidence(Duration::from_nanos(399), overhead));
+        assert!(!is_low_confidence(Duration::from_nanos(400), overhead));
+        assert!(!is_low_confidence(Duration::from_millis(1), overhead));
+        // Nothing is low-confidence against a zero overhead.
+        assert!(!is_low_confidence(Duration::ZERO, Duration::ZERO));
+    }
+
+    #[test]
+    fn test_low_confidence_phases() {
+        let overhead = Duration::from_nanos(100);
+        let phases = [
+            ("erasure", 0.0005),
+            ("commitment", 2.5),
+            ("verification_1", 0.0009),
+        ];
+        assert_eq!(
+            low_confidence_phases(&phases, overhead),
+            vec!["erasure", "verification_1"]
+        );
+    }
+}
@@ -0,0 +1,44 @@
+//! Records the git commit of the source tree for the benchmark's run metadata, so the binary
+//! reports the commit it was built from rather than whatever the checkout holds when it runs.
+
+use std::{path::Path, process::Command};
+
+fn git(args: &[&str]) -> Option<String> {
+    Command::new("git")
+        .args(args)
+        .output()
+        .ok()
+        .filter(|output| output.status.success())
+        .map(|output| String::from_utf8_lossy(&output.stdout).trim().to_string())
+}
+
+fn main() {
+    // Rebuilding on a changed source file keeps the dirty flag current, and on a new commit or
+    // branch the commit itself. Git resolves the paths, as `.git` is a file in a worktree.
+    for path in ["build.rs", "Cargo.toml", "src", "bench/src"] {
+        println!("cargo:rerun-if-changed={path}");
+    }
+    // A missing path makes cargo rerun on every build, so a packed branch is watched through
+    // `packed-refs` instead
+    let reference = git(&["symbolic-ref", "-q", "HEAD"]);
+    for name in ["HEAD"].into_iter().chain(reference.as_deref()) {
+        let path = git(&["rev-parse", "--git-path", name])
+            .filter(|path| Path::new(path).exists())
+            .or_else(|| git(&["rev-parse", "--git-path", "packed-refs"]));
+        if let Some(path) = path {
+            println!("cargo:rerun-if-changed={path}");
+        }
+    }
+
+    let Some(commit) = git(&["rev-parse", "HEAD"]) else {
+        return;
+    };
+    let dirty = git(&["status", "--porcelain", "--untracked-files=no"])
+        .is_some_and(|status| !status.is_empty());
+    let commit = if dirty {
+        format!("{commit}-dirty")
+    } else {
+        commit
+    };
+    println!("cargo:rustc-env=FRIDA_GIT_COMMIT={commit}");
+}
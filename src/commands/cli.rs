@@ -67,6 +67,9 @@ pub(super) enum Commands {
         /// Path to the commitment file
         #[arg(long, default_value = "data/commitment.bin")]
         commitment_path: PathBuf,
+        /// Hex-encoded commitment, e.g. copied from a log, used instead of the commitment file
+        #[arg(long, conflicts_with = "commitment_path")]
+        commitment_hex: Option<String>,
         /// Path to the positions file
         #[arg(long, default_value = "data/positions.bin")]
         positions_path: PathBuf,
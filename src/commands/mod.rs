@@ -94,6 +94,7 @@ fn handle_iteration(
         }
         Commands::Verify {
             commitment_path,
+            commitment_hex,
             positions_path,
             evaluations_path,
             proof_path,
@@ -101,14 +102,21 @@ fn handle_iteration(
             let builder = prover_builder
                 .as_ref()
                 .ok_or("Prover not initialized. Please run the 'init' command first.")?;
-            verify::run(
-                &commitment_path,
+            let commitment = match &commitment_hex {
+                Some(hex) => verify::CommitmentSource::Hex(hex),
+                None => verify::CommitmentSource::File(&commitment_path),
+            };
+            let elapsed = verify::run(
+                commitment,
                 &positions_path,
                 &evaluations_path,
                 &proof_path,
                 builder.options.clone(),
             )?;
-            println!("Verification successful!");
+            println!(
+                "Verification successful! ({:.3} ms)",
+                elapsed.as_secs_f64() * 1000.0
+            );
         }
     }
     Ok(())
@@ -1,26 +1,67 @@
 use crate::{
     commands::open::read_and_deserialize_proof, prover::Commitment, verifier::das::FridaDasVerifier,
 };
-use std::{error::Error, fs, path::Path};
+use std::{
+    error::Error,
+    fs,
+    path::Path,
+    time::{Duration, Instant},
+};
 use winter_crypto::hashers::Blake3_256;
 use winter_fri::FriOptions;
 use winter_math::fields::f128::BaseElement;
-use winter_utils::Deserializable;
+use winter_utils::{ByteReader, Deserializable, SliceReader};
 
 type Blake3 = Blake3_256<BaseElement>;
 type FriVerifierType = FridaDasVerifier<BaseElement, Blake3, Blake3>;
 
+/// Where the serialized commitment is read from.
+pub enum CommitmentSource<'a> {
+    File(&'a Path),
+    /// Hex string, e.g. copied from a log, with or without a `0x` prefix.
+    Hex(&'a str),
+}
+
+fn decode_hex(hex: &str) -> Result<Vec<u8>, String> {
+    let hex = hex.trim();
+    let digits = hex.strip_prefix("0x").unwrap_or(hex);
+    if let Some(position) = digits.bytes().position(|b| !b.is_ascii_hexdigit()) {
+        return Err(format!(
+            "Invalid commitment hex: non-hex character at position {position}"
+        ));
+    }
+    if digits.len() % 2 != 0 {
+        return Err(format!(
+            "Invalid commitment hex: odd number of digits ({})",
+            digits.len()
+        ));
+    }
+    Ok((0..digits.len())
+        .step_by(2)
+        .map(|i| u8::from_str_radix(&digits[i..i + 2], 16).unwrap())
+        .collect())
+}
+
+/// Verifies the proof against the commitment and returns the time taken by the verification
+/// itself, excluding reading the inputs and setting up the verifier.
 pub fn run(
-    commitment_path: &Path,
+    commitment: CommitmentSource,
     positions_path: &Path,
     evaluations_path: &Path,
     proof_path: &Path,
     fri_options: FriOptions,
-) -> Result<(), Box<dyn Error>> {
+) -> Result<Duration, Box<dyn Error>> {
     // Read and deserialize
-    let commitment_bytes = fs::read(commitment_path)?;
-    let commitment = Commitment::<Blake3_256<BaseElement>>::read_from_bytes(&commitment_bytes)
+    let commitment_bytes = match commitment {
+        CommitmentSource::File(path) => fs::read(path)?,
+        CommitmentSource::Hex(hex) => decode_hex(hex)?,
+    };
+    let mut reader = SliceReader::new(&commitment_bytes);
+    let commitment = Commitment::<Blake3_256<BaseElement>>::read_from(&mut reader)
         .map_err(|e| format!("Deserialization error: {e}"))?;
+    if reader.has_more_bytes() {
+        return Err("Deserialization error: unexpected bytes after the commitment".into());
+    }
 
     let (positions, evaluations, proof) =
         read_and_deserialize_proof(positions_path, evaluations_path, proof_path)?;
@@ -29,11 +70,12 @@ pub fn run(
         .map_err(|e| format!("Verifier initialization error: {e}"))?;
 
     // Verify the proof
+    let timer = Instant::now();
     verifier
         .verify(&proof, &evaluations, &positions)
         .map_err(|e| format!("Verification error: {e}"))?;
 
-    Ok(())
+    Ok(timer.elapsed())
 }
 
 #[cfg(test)]
@@ -88,12 +130,52 @@ mod tests {
 
         // Verify the proof
         let result = run(
-            commitment_path,
+            CommitmentSource::File(commitment_path),
             positions_path,
             evaluations_path,
             proof_path,
             prover_builder.options.clone(),
         );
         assert!(result.is_ok(), "{:?}", result.err().unwrap());
+
+        // The same commitment pasted as hex
+        let hex = fs::read(commitment_path)
+            .unwrap()
+            .iter()
+            .map(|b| format!("{b:02x}"))
+            .collect::<String>();
+        let verify_hex = |hex: &str| {
+            run(
+                CommitmentSource::Hex(hex),
+                positions_path,
+                evaluations_path,
+                proof_path,
+                prover_builder.options.clone(),
+            )
+            .map_err(|e| e.to_string())
+        };
+        assert!(verify_hex(&hex).is_ok());
+        assert!(verify_hex(&format!("0x{hex}")).is_ok());
+        let err = verify_hex(&hex[..hex.len() - 2]).unwrap_err();
+        assert!(err.starts_with("Deserialization error"), "{err}");
+        assert_eq!(
+            verify_hex(&format!("{hex}00")).unwrap_err(),
+            "Deserialization error: unexpected bytes after the commitment"
+        );
+    }
+
+    #[test]
+    fn test_decode_hex() {
+        assert_eq!(decode_hex("0x00ff1A"), Ok(vec![0x00, 0xff, 0x1a]));
+        assert_eq!(decode_hex(" abcd\n"), Ok(vec![0xab, 0xcd]));
+        assert_eq!(decode_hex(""), Ok(vec![]));
+        assert_eq!(
+            decode_hex("0xabc"),
+            Err("Invalid commitment hex: odd number of digits (3)".to_string())
+        );
+        assert_eq!(
+            decode_hex("ab+c"),
+            Err("Invalid commitment hex: non-hex character at position 2".to_string())
+        );
     }
 }
@@ -1,6 +1,9 @@
 use crate::error::FridaError;
 use core::mem;
 use winter_math::{fft, polynom, FieldElement, StarkField};
+use winter_utils::iter_mut;
+#[cfg(feature = "concurrent")]
+use winter_utils::iterators::*;
 
 pub fn encoded_data_element_count<E: FieldElement>(data_size: usize) -> usize {
     let element_size = E::ELEMENT_BYTES - 1;
@@ -19,20 +22,27 @@ fn encode_data<E: FieldElement>(data: &[u8], domain_size: usize, blowup_factor:
 
     let mut encoded_data = vec![0; encoded_element_count * E::ELEMENT_BYTES];
 
-    let data_size_bytes = (data_size as u64).to_be_bytes();
-    let mut index = 0;
-    for byte in data_size_bytes {
-        encoded_data[index] = byte;
-        index += 1;
-    }
+    // The first element starts with the data size and holds as much data as fits after it
+    let header = mem::size_of::<u64>();
+    encoded_data[..header].copy_from_slice(&(data_size as u64).to_be_bytes());
+    let (head, tail) = data.split_at(E::ELEMENT_BYTES.saturating_sub(header + 1).min(data_size));
+    encoded_data[header..header + head.len()].copy_from_slice(head);
 
-    data.iter().for_each(|byte| {
-        if (index + 1) % E::ELEMENT_BYTES == 0 {
-            index += 1;
-        }
-        encoded_data[index] = *byte;
-        index += 1;
-    });
+    // Every other element holds element_size bytes of data, leaving its last byte zero
+    let element_size = E::ELEMENT_BYTES - 1;
+    #[cfg(not(feature = "concurrent"))]
+    let (elements, chunks) = (
+        encoded_data[E::ELEMENT_BYTES..].chunks_mut(E::ELEMENT_BYTES),
+        tail.chunks(element_size),
+    );
+    #[cfg(feature = "concurrent")]
+    let (elements, chunks) = (
+        encoded_data[E::ELEMENT_BYTES..].par_chunks_mut(E::ELEMENT_BYTES),
+        tail.par_chunks(element_size),
+    );
+    elements
+        .zip(chunks)
+        .for_each(|(element, chunk)| element[..chunk.len()].copy_from_slice(chunk));
 
     encoded_data
 }
@@ -42,12 +52,16 @@ fn data_to_field_element<E: FieldElement>(
     domain_size: usize,
 ) -> Result<Vec<E>, FridaError> {
     let mut symbols = Vec::with_capacity(domain_size);
-    for chunk in encoded_data.chunks(E::ELEMENT_BYTES) {
-        match E::read_from_bytes(chunk) {
-            Ok(val) => symbols.push(val),
-            Err(e) => return Err(FridaError::DeserializationError(e)),
-        };
-    }
+    symbols.resize(encoded_data.len() / E::ELEMENT_BYTES, E::ZERO);
+
+    #[cfg(not(feature = "concurrent"))]
+    let chunks = encoded_data.chunks(E::ELEMENT_BYTES);
+    #[cfg(feature = "concurrent")]
+    let chunks = encoded_data.par_chunks(E::ELEMENT_BYTES);
+    iter_mut!(symbols)
+        .zip(chunks)
+        .try_for_each(|(symbol, chunk)| E::read_from_bytes(chunk).map(|value| *symbol = value))
+        .map_err(FridaError::DeserializationError)?;
     Ok(symbols)
 }
 
@@ -163,7 +177,45 @@ pub fn recover_data_from_evaluations<E: FieldElement>(
 #[cfg(test)]
 mod tests {
     use super::*;
-    use winter_math::fields::f128::BaseElement;
+    use winter_math::fields::{f128::BaseElement, f64};
+
+    /// Byte-at-a-time encoding that [`encode_data`] must match.
+    fn encode_data_serially<E: FieldElement>(data: &[u8]) -> Vec<u8> {
+        let mut encoded_data =
+            vec![0; encoded_data_element_count::<E>(data.len()) * E::ELEMENT_BYTES];
+        let mut index = 0;
+        for byte in (data.len() as u64).to_be_bytes() {
+            encoded_data[index] = byte;
+            index += 1;
+        }
+        for byte in data {
+            if (index + 1) % E::ELEMENT_BYTES == 0 {
+                index += 1;
+            }
+            encoded_data[index] = *byte;
+            index += 1;
+        }
+        encoded_data
+    }
+
+    #[test]
+    fn test_encode_data_matches_serial_encoding() {
+        // Sizes around the element boundaries of both fields, where the last element is partial
+        for size in [1, 6, 7, 8, 14, 15, 16, 31, 100, 1021, 4099] {
+            let data = (0..size).map(|i| (i % 251) as u8 + 1).collect::<Vec<_>>();
+            let domain_size = 8192;
+            assert_eq!(
+                encode_data::<f64::BaseElement>(&data, domain_size, 2),
+                encode_data_serially::<f64::BaseElement>(&data),
+                "f64, {size} bytes"
+            );
+            assert_eq!(
+                encode_data::<BaseElement>(&data, domain_size, 2),
+                encode_data_serially::<BaseElement>(&data),
+                "f128, {size} bytes"
+            );
+        }
+    }
 
     #[test]
     fn test_build_evaluations_from_data() {
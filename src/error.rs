@@ -21,6 +21,8 @@ pub enum FridaError {
     ProofPolyCountMismatch,
     /// Occurs when the blowup factor is less than or equal to 1.
     InvalidBlowupFactor,
+    /// Occurs when the data to commit to contains no bytes.
+    EmptyData,
 }
 
 impl fmt::Display for FridaError {
@@ -65,6 +67,7 @@ impl fmt::Display for FridaError {
                     "Blowup factor must be greater than 1 for query calculation."
                 )
             }
+            FridaError::EmptyData => write!(f, "Cannot commit to empty data"),
         }
     }
 }
@@ -115,9 +115,17 @@ where
 
     /// It calculates the domain size and generates the initial evaluations.
     fn prepare_prover_state(&self, data: &[u8], num_queries: usize) -> ProverStateResult<E, H> {
+        #[cfg(feature = "bench")]
+        unsafe {
+            bench::TIMER = Some(Instant::now());
+        }
+
         if num_queries == 0 {
             return Err(FridaError::BadNumQueries(num_queries));
         }
+        if data.is_empty() {
+            return Err(FridaError::EmptyData);
+        }
 
         let blowup_factor = self.options.blowup_factor();
         let encoded_element_count = encoded_data_element_count::<E>(data.len());
@@ -133,6 +141,13 @@ where
 
         let evaluations = build_evaluations_from_data(data, domain_size, blowup_factor)?;
 
+        #[cfg(feature = "bench")]
+        unsafe {
+            bench::ERASURE_TIME =
+                Some(bench::ERASURE_TIME.unwrap_or_default() + bench::TIMER.unwrap().elapsed());
+            bench::TIMER = Some(Instant::now());
+        }
+
         if num_queries >= domain_size {
             return Err(FridaError::BadNumQueries(num_queries));
         }
@@ -163,6 +178,9 @@ where
         if poly_count <= 1 {
             return Err(FridaError::SinglePolyBatch);
         }
+        if data_list.iter().all(|data| data.is_empty()) {
+            return Err(FridaError::EmptyData);
+        }
 
         let blowup_factor = self.options.blowup_factor();
 
@@ -147,6 +147,27 @@ fn verify_batch(
     query_positions.dedup();
     query_positions = fold_positions(&query_positions, domain_size, folding_factor);
 
+    let evaluations = batch_query_evaluations(
+        data_evaluations,
+        &query_positions,
+        poly_count,
+        folding_factor,
+        domain_size,
+    );
+
+    verifier
+        .verify(&proof, &evaluations, &query_positions)
+        .unwrap();
+}
+
+/// Collects the evaluations of every polynomial in the batch at each queried position.
+fn batch_query_evaluations(
+    data_evaluations: &[BaseElement],
+    query_positions: &[usize],
+    poly_count: usize,
+    folding_factor: usize,
+    domain_size: usize,
+) -> Vec<BaseElement> {
     let mut evaluations = vec![];
     for position in query_positions.iter() {
         let bucket = position % (domain_size / folding_factor);
@@ -158,10 +179,7 @@ fn verify_batch(
                 evaluations.push(*e);
             });
     }
-
-    verifier
-        .verify(&proof, &evaluations, &query_positions)
-        .unwrap();
+    evaluations
 }
 
 #[test]
@@ -218,3 +236,44 @@ fn test_batching_only_batch_layer() {
         domain_size,
     );
 }
+
+#[test]
+fn test_verify_batch_rejects_swapped_poly_evaluations() {
+    let data = vec![rand_vector::<u8>(64), rand_vector::<u8>(64)];
+
+    let options = FriOptions::new(2, 2, 0);
+    let folding_factor = options.folding_factor();
+    let prover_builder = TestFridaProverBuilder::new(options.clone());
+
+    let (commitment, prover) = prover_builder.commit_and_prove_batch(&data, 4).unwrap();
+    let proof = commitment.proof.clone();
+    let domain_size = commitment.domain_size;
+    let poly_count = commitment.poly_count;
+
+    let (verifier, coin) = TestFridaDasVerifier::new(commitment, options.clone()).unwrap();
+
+    let mut query_positions = coin.draw_query_positions(4, domain_size).unwrap();
+    query_positions.dedup();
+    query_positions = fold_positions(&query_positions, domain_size, folding_factor);
+
+    let evaluations = batch_query_evaluations(
+        prover.get_first_layer_evaluations(),
+        &query_positions,
+        poly_count,
+        folding_factor,
+        domain_size,
+    );
+    verifier
+        .verify(&proof, &evaluations, &query_positions)
+        .unwrap();
+
+    // Attribute every queried value to the other polynomial of the batch
+    let mut swapped_evaluations = evaluations.clone();
+    swapped_evaluations
+        .chunks_mut(poly_count)
+        .for_each(|values| values.swap(0, 1));
+    assert_ne!(swapped_evaluations, evaluations);
+    assert!(verifier
+        .verify(&proof, &swapped_evaluations, &query_positions)
+        .is_err());
+}
@@ -1,5 +1,9 @@
 use crate::{
-    core::data::{build_evaluations_from_data, encoded_data_element_count},
+    constants,
+    core::data::{
+        build_evaluations_from_data, encoded_data_element_count, recover_data_from_evaluations,
+    },
+    error::FridaError,
     utils::test_utils::{TestFridaDasVerifier, TestFridaProverBuilder},
 };
 use winter_fri::FriOptions;
@@ -74,3 +78,64 @@ fn test_frida_das_verify() {
         .verify(&proof, &queried_evaluations, &open_position)
         .unwrap();
 }
+
+#[test]
+fn test_frida_das_verify_tiny_inputs() {
+    let options = FriOptions::new(2, 2, 0);
+    let prover_builder = TestFridaProverBuilder::new(options.clone());
+
+    // Sizes around a single field element (15 usable bytes for f128) all land on the minimum
+    // domain and must still commit, verify and decode.
+    for data_size in [1, 31, 32, 33] {
+        let data = rand_vector::<u8>(data_size);
+        let (commitment, prover) = prover_builder.commit_and_prove(&data, 4).unwrap();
+        let domain_size = commitment.domain_size;
+        assert_eq!(domain_size, constants::MIN_DOMAIN_SIZE);
+
+        let (verifier, _coin) = TestFridaDasVerifier::new(commitment, options.clone()).unwrap();
+
+        let open_positions = [0, domain_size - 1];
+        let proof = prover.open(&open_positions);
+
+        let evaluations: Vec<BaseElement> =
+            build_evaluations_from_data(&data, domain_size, options.blowup_factor()).unwrap();
+        let queried_evaluations = open_positions
+            .iter()
+            .map(|&p| evaluations[p])
+            .collect::<Vec<_>>();
+        verifier
+            .verify(&proof, &queried_evaluations, &open_positions)
+            .unwrap();
+
+        let positions = (0..domain_size).collect::<Vec<_>>();
+        let recovered = recover_data_from_evaluations(
+            &evaluations,
+            &positions,
+            domain_size,
+            options.blowup_factor(),
+        )
+        .unwrap();
+        assert_eq!(data, recovered);
+    }
+}
+
+#[test]
+fn test_commit_empty_data() {
+    let options = FriOptions::new(2, 2, 0);
+    let prover_builder = TestFridaProverBuilder::new(options);
+
+    assert_eq!(
+        prover_builder.commit_and_prove(&[], 4).err(),
+        Some(FridaError::EmptyData)
+    );
+    assert_eq!(
+        prover_builder
+            .commit_and_prove_batch(&[vec![], vec![]], 4)
+            .err(),
+        Some(FridaError::EmptyData)
+    );
+    // A batch only needs one non-empty entry.
+    assert!(prover_builder
+        .commit_and_prove_batch(&[vec![], vec![1]], 4)
+        .is_ok());
+}